@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/config"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"go.uber.org/zap"
+)
+
+func main() {
+	// Initialize context
+	ctx, err := config.InitContext()
+	if err != nil {
+		log.Fatalf("Failed to initialize context: %v", err)
+	}
+
+	defer func() {
+		if err := ctx.Logger.Sync(); err != nil {
+			fmt.Printf("Failed to sync logger: %v\n", err)
+		}
+	}()
+
+	// Ensure the database connection is closed when the worker exits
+	sqlDB, err := ctx.DB.DB()
+	if err != nil {
+		ctx.Logger.Fatal("Failed to get underlying SQL DB from GORM DB", zap.Error(err))
+	}
+	defer func() {
+		if err := sqlDB.Close(); err != nil {
+			ctx.Logger.Fatal("Failed to close database connection", zap.Error(err))
+		}
+	}()
+
+	pollInterval := 5 * time.Second
+	if value := os.Getenv("WORKER_POLL_INTERVAL"); value != "" {
+		pollInterval, err = time.ParseDuration(value)
+		if err != nil {
+			ctx.Logger.Fatal("Failed to parse WORKER_POLL_INTERVAL", zap.Error(err))
+		}
+	}
+
+	schedulerInterval := time.Minute
+	if value := os.Getenv("SCHEDULER_INTERVAL"); value != "" {
+		schedulerInterval, err = time.ParseDuration(value)
+		if err != nil {
+			ctx.Logger.Fatal("Failed to parse SCHEDULER_INTERVAL", zap.Error(err))
+		}
+	}
+
+	webhookInterval := 10 * time.Second
+	if value := os.Getenv("WEBHOOK_INTERVAL"); value != "" {
+		webhookInterval, err = time.ParseDuration(value)
+		if err != nil {
+			ctx.Logger.Fatal("Failed to parse WEBHOOK_INTERVAL", zap.Error(err))
+		}
+	}
+
+	digestInterval := 15 * time.Minute
+	if value := os.Getenv("DIGEST_INTERVAL"); value != "" {
+		digestInterval, err = time.ParseDuration(value)
+		if err != nil {
+			ctx.Logger.Fatal("Failed to parse DIGEST_INTERVAL", zap.Error(err))
+		}
+	}
+
+	notificationInterval := 30 * time.Second
+	if value := os.Getenv("NOTIFICATION_EMAIL_INTERVAL"); value != "" {
+		notificationInterval, err = time.ParseDuration(value)
+		if err != nil {
+			ctx.Logger.Fatal("Failed to parse NOTIFICATION_EMAIL_INTERVAL", zap.Error(err))
+		}
+	}
+
+	// Stop claiming new jobs on shutdown, a running job is allowed to finish
+	stop, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	go services.RunScheduler(ctx, stop, schedulerInterval)
+	go services.RunWebhookDeliveries(ctx, stop, webhookInterval)
+	mailer := services.NewMailer()
+	go services.RunDigests(ctx, stop, digestInterval, mailer)
+	go services.RunNotificationEmails(ctx, stop, notificationInterval, mailer)
+
+	ctx.Logger.Info("Worker started", zap.Duration("poll_interval", pollInterval))
+
+	for stop.Err() == nil {
+		job, err := services.ClaimSyncJob(ctx)
+		if err != nil {
+			ctx.Logger.Error("Failed to claim sync job", zap.Error(err))
+		}
+
+		if job != nil {
+			ctx.Logger.Info("Running sync job", zap.String("job_id", job.ID.String()), zap.String("project_id", job.ProjectID.String()))
+			if err := services.RunSyncJob(ctx, job); err != nil {
+				ctx.Logger.Error("Failed to run sync job", zap.Error(err), zap.String("job_id", job.ID.String()))
+			}
+			continue
+		}
+
+		select {
+		case <-stop.Done():
+		case <-time.After(pollInterval):
+		}
+	}
+
+	ctx.Logger.Info("Worker stopped")
+}
@@ -90,17 +90,68 @@ func InitDB() (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	err = db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"").Error
+	if err := Migrate(db); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrate brings the schema of the database up to date with the entities.
+func Migrate(db *gorm.DB) error {
+	err := db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"").Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to enable uuid-ossp extension: %w", err)
+		return fmt.Errorf("failed to enable uuid-ossp extension: %w", err)
+	}
+
+	// Columns used to be unique by name within a table. Backfill the path of
+	// existing rows before the unique index on it is created.
+	if db.Migrator().HasTable(&entity.Column{}) && !db.Migrator().HasColumn(&entity.Column{}, "Path") {
+		if err := db.Exec("ALTER TABLE columns ADD COLUMN path text").Error; err != nil {
+			return fmt.Errorf("failed to add column path: %w", err)
+		}
+		if err := db.Exec("UPDATE columns SET path = name").Error; err != nil {
+			return fmt.Errorf("failed to backfill column path: %w", err)
+		}
 	}
 
-	err = db.AutoMigrate(&entity.Company{}, &entity.User{}, &entity.KeyFile{}, &entity.Dataset{}, &entity.Table{}, &entity.Column{}, &entity.Sync{}, &entity.Project{}, &entity.Changelog{})
+	err = db.AutoMigrate(&entity.Company{}, &entity.User{}, &entity.KeyFile{}, &entity.Connection{}, &entity.Dataset{}, &entity.Table{}, &entity.Column{}, &entity.Sync{}, &entity.SyncJob{}, &entity.SyncSchedule{}, &entity.SyncFilter{},
+		&entity.SyncWarning{}, &entity.Project{}, &entity.Changelog{}, &entity.ColumnRename{}, &entity.CompatibilityPolicy{}, &entity.Webhook{}, &entity.WebhookDelivery{}, &entity.DigestSubscription{},
+		&entity.Watch{}, &entity.Notification{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	return db, nil
+	// A project has at most one queued or running sync job of each kind. Jobs
+	// that were queued twice before the index existed are failed first.
+	if err := db.Exec(`UPDATE sync_jobs SET status = 'failed', error = 'duplicate sync job' WHERE status IN ('queued', 'running') AND EXISTS (
+		SELECT 1 FROM sync_jobs active WHERE active.project_id = sync_jobs.project_id AND active.dry_run = sync_jobs.dry_run
+		AND active.status IN ('queued', 'running') AND active.created_at < sync_jobs.created_at)`).Error; err != nil {
+		return fmt.Errorf("failed to fail duplicate sync jobs: %w", err)
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_sync_job_active ON sync_jobs (project_id, dry_run) WHERE status IN ('queued', 'running')").Error; err != nil {
+		return fmt.Errorf("failed to create active sync job index: %w", err)
+	}
+
+	// Columns stored before positions were recorded all sit at position 0.
+	// Clearing the fingerprint of their tables makes the next sync read them
+	// again, which backfills the positions.
+	if err := db.Exec(`UPDATE tables SET schema_fingerprint = '', last_modified_time = NULL WHERE id IN (
+		SELECT table_id FROM columns WHERE deleted_at IS NULL GROUP BY table_id, parent_id HAVING COUNT(*) > 1 AND MAX(position) = 0)`).Error; err != nil {
+		return fmt.Errorf("failed to backfill column positions: %w", err)
+	}
+
+	if db.Migrator().HasIndex(&entity.Column{}, "idx_column_name_table") {
+		if err := db.Migrator().DropIndex(&entity.Column{}, "idx_column_name_table"); err != nil {
+			return fmt.Errorf("failed to drop column name index: %w", err)
+		}
+	}
+
+	if err := db.Exec("UPDATE changelogs SET project_id = syncs.project_id FROM syncs WHERE changelogs.sync_id = syncs.id AND changelogs.project_id IS NULL").Error; err != nil {
+		return fmt.Errorf("failed to backfill changelog project: %w", err)
+	}
+
+	return nil
 }
 
 func InitLogger() (*zap.Logger, error) {
@@ -165,7 +216,9 @@ func InitMeilisearch() (*meilisearch.Client, error) {
 	// Set searchable attributes
 	task, err = client.Index("resources").UpdateSearchableAttributes(&[]string{
 		"name",
+		"path",
 		"description",
+		"curated_description",
 		"type",
 		"column_type",
 	})
@@ -0,0 +1,233 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+type ServiceAccountKey struct {
+	Type                    string `json:"type"`
+	ProjectID               string `json:"project_id"`
+	PrivateKeyID            string `json:"private_key_id"`
+	PrivateKey              string `json:"private_key"`
+	ClientEmail             string `json:"client_email"`
+	ClientID                string `json:"client_id"`
+	AuthURI                 string `json:"auth_uri"`
+	TokenURI                string `json:"token_uri"`
+	AuthProviderX509CertURL string `json:"auth_provider_x509_cert_url"`
+	ClientX509CertURL       string `json:"client_x509_cert_url"`
+}
+
+// BigQueryConnector reads metadata with the BigQuery API. The service account
+// needs the BigQuery Metadata Viewer role to list and describe tables.
+type BigQueryConnector struct {
+	client *bigquery.Client
+}
+
+// OpenBigQuery reads the project's service account key from GCS and creates
+// a BigQuery client authenticated with it.
+func OpenBigQuery(ctx *appcontext.Context, projectID uuid.UUID) (*BigQueryConnector, error) {
+	var keyFile entity.KeyFile
+	if err := ctx.DB.Where("project_id = ?", projectID).First(&keyFile).Error; err != nil {
+		return nil, fmt.Errorf("failed to get key file for project: %w", err)
+	}
+
+	rc, err := ctx.GCSClient.Bucket(ctx.GCSBucketName).Object(projectID.String() + "/sa_key").NewReader(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch key file from GCS: %w", err)
+	}
+	defer rc.Close()
+
+	keyFileBytes, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file from GCS: %w", err)
+	}
+
+	return NewBigQueryConnector(keyFileBytes)
+}
+
+func NewBigQueryConnector(keyFileBytes []byte) (*BigQueryConnector, error) {
+	var key ServiceAccountKey
+	if err := json.Unmarshal(keyFileBytes, &key); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal key file: %w", err)
+	}
+
+	conf, err := google.JWTConfigFromJSON(keyFileBytes, bigquery.Scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	client, err := bigquery.NewClient(context.Background(), key.ProjectID, option.WithTokenSource(conf.TokenSource(context.Background())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery client: %w", err)
+	}
+
+	return &BigQueryConnector{client: client}, nil
+}
+
+func (b *BigQueryConnector) ListDatasets(ctx context.Context) ([]DatasetMetadata, error) {
+	var datasets []DatasetMetadata
+
+	it := b.client.Datasets(ctx)
+	for {
+		ds, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch datasets: %w", err)
+		}
+
+		var meta *bigquery.DatasetMetadata
+		err = withRetry(ctx, func() (err error) {
+			meta, err = ds.Metadata(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch dataset metadata: %w", err)
+		}
+
+		datasets = append(datasets, DatasetMetadata{
+			Name:        ds.DatasetID,
+			Description: meta.Description,
+		})
+	}
+
+	return datasets, nil
+}
+
+func (b *BigQueryConnector) ListTables(ctx context.Context, dataset string) ([]TableRef, error) {
+	var tables []TableRef
+
+	it := b.client.Dataset(dataset).Tables(ctx)
+	for {
+		tbl, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tables: %w", err)
+		}
+
+		tables = append(tables, TableRef{Name: tbl.TableID})
+	}
+
+	return tables, nil
+}
+
+// ListModifiedTimes queries the __TABLES__ meta-table of the dataset. The
+// tables.list API does not return last modified times, so unlike the other
+// metadata calls this runs a query job. The job is billed and needs the
+// bigquery.jobs.create permission, e.g. from the BigQuery Job User role.
+func (b *BigQueryConnector) ListModifiedTimes(ctx context.Context, dataset string) (map[string]time.Time, error) {
+	q := b.client.Query(fmt.Sprintf("SELECT table_id, last_modified_time FROM `%s.%s.__TABLES__`", b.client.Project(), dataset))
+
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table metadata: %w", err)
+	}
+
+	lastModified := make(map[string]time.Time)
+	for {
+		var row struct {
+			TableID          string `bigquery:"table_id"`
+			LastModifiedTime int64  `bigquery:"last_modified_time"`
+		}
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read table metadata: %w", err)
+		}
+		lastModified[row.TableID] = time.UnixMilli(row.LastModifiedTime)
+	}
+
+	return lastModified, nil
+}
+
+func (b *BigQueryConnector) DescribeTable(ctx context.Context, dataset, table string) (*TableMetadata, error) {
+	var tblMeta *bigquery.TableMetadata
+	err := withRetry(ctx, func() (err error) {
+		tblMeta, err = b.client.Dataset(dataset).Table(table).Metadata(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch table metadata: %w", err)
+	}
+
+	metadata := &TableMetadata{
+		Name:             table,
+		Description:      tblMeta.Description,
+		Type:             string(tblMeta.Type),
+		RowCount:         tblMeta.NumRows,
+		NumBytes:         tblMeta.NumBytes,
+		Location:         tblMeta.Location,
+		Labels:           tblMeta.Labels,
+		ExpirationTime:   tblMeta.ExpirationTime,
+		CreationTime:     tblMeta.CreationTime,
+		LastModifiedTime: tblMeta.LastModifiedTime,
+	}
+
+	if tp := tblMeta.TimePartitioning; tp != nil {
+		metadata.TimePartitioning = &TimePartitioning{
+			Type:                   string(tp.Type),
+			Field:                  tp.Field,
+			Expiration:             tp.Expiration,
+			RequirePartitionFilter: tblMeta.RequirePartitionFilter,
+		}
+	}
+
+	if rp := tblMeta.RangePartitioning; rp != nil {
+		metadata.RangePartitioning = &RangePartitioning{Field: rp.Field}
+		if rp.Range != nil {
+			metadata.RangePartitioning.Start = rp.Range.Start
+			metadata.RangePartitioning.End = rp.Range.End
+			metadata.RangePartitioning.Interval = rp.Range.Interval
+		}
+	}
+
+	if tblMeta.Clustering != nil {
+		metadata.Clustering = tblMeta.Clustering.Fields
+	}
+
+	metadata.Columns = schemaToColumns(tblMeta.Schema)
+
+	return metadata, nil
+}
+
+func schemaToColumns(schema bigquery.Schema) []ColumnMetadata {
+	var columns []ColumnMetadata
+	for _, fieldSchema := range schema {
+		mode := ColumnModeNullable
+		if fieldSchema.Repeated {
+			mode = ColumnModeRepeated
+		} else if fieldSchema.Required {
+			mode = ColumnModeRequired
+		}
+
+		columns = append(columns, ColumnMetadata{
+			Name:        fieldSchema.Name,
+			Type:        string(fieldSchema.Type),
+			Mode:        mode,
+			Description: fieldSchema.Description,
+			Fields:      schemaToColumns(fieldSchema.Schema),
+		})
+	}
+	return columns
+}
+
+func (b *BigQueryConnector) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,173 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+)
+
+const (
+	TableTypeTable            = "TABLE"
+	TableTypeView             = "VIEW"
+	TableTypeMaterializedView = "MATERIALIZED_VIEW"
+	TableTypeExternal         = "EXTERNAL"
+)
+
+const (
+	ColumnModeNullable = "NULLABLE"
+	ColumnModeRequired = "REQUIRED"
+	ColumnModeRepeated = "REPEATED"
+)
+
+const (
+	SourceTypeBigQuery = "bigquery"
+	SourceTypePostgres = "postgres"
+	SourceTypeMySQL    = "mysql"
+)
+
+type DatasetMetadata struct {
+	Name        string
+	Description string
+}
+
+type TableRef struct {
+	Name string
+}
+
+type TableMetadata struct {
+	Name              string
+	Description       string
+	Type              string
+	RowCount          uint64
+	NumBytes          int64
+	Location          string
+	TimePartitioning  *TimePartitioning
+	RangePartitioning *RangePartitioning
+	Clustering        []string
+	Labels            map[string]string
+	ExpirationTime    time.Time
+	CreationTime      time.Time
+	LastModifiedTime  time.Time
+	Columns           []ColumnMetadata
+}
+
+type TimePartitioning struct {
+	Type                   string        `json:"type"`
+	Field                  string        `json:"field,omitempty"`
+	Expiration             time.Duration `json:"expiration,omitempty"`
+	RequirePartitionFilter bool          `json:"require_partition_filter,omitempty"`
+}
+
+type RangePartitioning struct {
+	Field    string `json:"field"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Interval int64  `json:"interval"`
+}
+
+type ColumnMetadata struct {
+	Name        string
+	Type        string
+	Mode        string
+	Description string
+	// Fields holds the nested fields of RECORD/STRUCT columns.
+	Fields []ColumnMetadata
+}
+
+// Connector reads catalog metadata from a source warehouse. Datasets map to
+// entity.Dataset, tables to entity.Table and columns to entity.Column.
+type Connector interface {
+	ListDatasets(ctx context.Context) ([]DatasetMetadata, error)
+	ListTables(ctx context.Context, dataset string) ([]TableRef, error)
+	DescribeTable(ctx context.Context, dataset, table string) (*TableMetadata, error)
+	Close() error
+}
+
+// ModifiedTimesLister is implemented by connectors that can list the last
+// modified times of a dataset's tables in one call, so that unchanged tables
+// are skipped without being described. The call may be billed by the source,
+// so syncs only make it if the project opts in.
+type ModifiedTimesLister interface {
+	ListModifiedTimes(ctx context.Context, dataset string) (map[string]time.Time, error)
+}
+
+// ConnectionConfig holds the credentials of a database source. It is stored
+// as JSON in GCS next to the project's other files.
+type ConnectionConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Database string `json:"database"`
+	SSLMode  string `json:"sslmode"`
+}
+
+// Open returns the connector configured for the given project.
+func Open(ctx *appcontext.Context, projectID uuid.UUID) (Connector, error) {
+	var project entity.Project
+	if err := ctx.DB.Where("id = ?", projectID).First(&project).Error; err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	switch project.SourceType {
+	case SourceTypeBigQuery, "":
+		conn, err := OpenBigQuery(ctx, projectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open BigQuery connector: %w", err)
+		}
+		return conn, nil
+	case SourceTypePostgres:
+		config, err := readConnectionConfig(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := NewPostgresConnector(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open Postgres connector: %w", err)
+		}
+		return conn, nil
+	case SourceTypeMySQL:
+		config, err := readConnectionConfig(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := NewMySQLConnector(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open MySQL connector: %w", err)
+		}
+		return conn, nil
+	default:
+		return nil, fmt.Errorf("unsupported source type: %s", project.SourceType)
+	}
+}
+
+func readConnectionConfig(ctx *appcontext.Context, projectID uuid.UUID) (*ConnectionConfig, error) {
+	var connection entity.Connection
+	if err := ctx.DB.Where("project_id = ?", projectID).First(&connection).Error; err != nil {
+		return nil, fmt.Errorf("failed to get connection for project: %w", err)
+	}
+
+	rc, err := ctx.GCSClient.Bucket(ctx.GCSBucketName).Object(projectID.String() + "/connection").NewReader(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch connection from GCS: %w", err)
+	}
+	defer rc.Close()
+
+	connectionBytes, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection from GCS: %w", err)
+	}
+
+	var config ConnectionConfig
+	if err := json.Unmarshal(connectionBytes, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal connection: %w", err)
+	}
+
+	return &config, nil
+}
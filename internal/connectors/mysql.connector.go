@@ -0,0 +1,178 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type MySQLConnector struct {
+	db *sql.DB
+}
+
+func NewMySQLConnector(config *ConnectionConfig) (*MySQLConnector, error) {
+	mysqlConfig, err := newMySQLConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", mysqlConfig.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MySQL connection: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to MySQL: %w", err)
+	}
+
+	return &MySQLConnector{db: db}, nil
+}
+
+// newMySQLConfig returns the driver config for config. The sslmode takes the
+// Postgres values: connections are encrypted unless it is "disable", and the
+// server certificate is only verified with "verify-ca" or "verify-full".
+func newMySQLConfig(config *ConnectionConfig) (*mysql.Config, error) {
+	port := config.Port
+	if port == 0 {
+		port = 3306
+	}
+
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = config.Host + ":" + strconv.Itoa(port)
+	mysqlConfig.User = config.User
+	mysqlConfig.Passwd = config.Password
+	mysqlConfig.DBName = config.Database
+	mysqlConfig.ParseTime = true
+
+	switch config.SSLMode {
+	case "", "require":
+		mysqlConfig.TLSConfig = "skip-verify"
+	case "verify-ca", "verify-full":
+		mysqlConfig.TLSConfig = "true"
+	case "prefer":
+		mysqlConfig.TLSConfig = "preferred"
+	case "disable":
+	default:
+		return nil, fmt.Errorf("unsupported sslmode %q", config.SSLMode)
+	}
+
+	return mysqlConfig, nil
+}
+
+func (m *MySQLConnector) ListDatasets(ctx context.Context) ([]DatasetMetadata, error) {
+	// MySQL schemas have no comments, so datasets are cataloged without a description.
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT SCHEMA_NAME
+		FROM information_schema.SCHEMATA
+		WHERE SCHEMA_NAME NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')
+		ORDER BY SCHEMA_NAME`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var datasets []DatasetMetadata
+	for rows.Next() {
+		var dataset DatasetMetadata
+		if err := rows.Scan(&dataset.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan schema: %w", err)
+		}
+		datasets = append(datasets, dataset)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch schemas: %w", err)
+	}
+
+	return datasets, nil
+}
+
+func (m *MySQLConnector) ListTables(ctx context.Context, dataset string) ([]TableRef, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT TABLE_NAME
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME`, dataset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableRef
+	for rows.Next() {
+		var table TableRef
+		if err := rows.Scan(&table.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %w", err)
+	}
+
+	return tables, nil
+}
+
+func (m *MySQLConnector) DescribeTable(ctx context.Context, dataset, table string) (*TableMetadata, error) {
+	metadata := &TableMetadata{Name: table}
+
+	// TABLE_ROWS is exact for MyISAM but only an estimate for InnoDB, and NULL for views.
+	var rowCount, numBytes sql.NullInt64
+	var tableType string
+	var createTime, updateTime sql.NullTime
+	if err := m.db.QueryRowContext(ctx, `
+		SELECT COALESCE(TABLE_COMMENT, ''), TABLE_ROWS, TABLE_TYPE,
+			DATA_LENGTH + INDEX_LENGTH, CREATE_TIME, UPDATE_TIME
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`, dataset, table).Scan(&metadata.Description, &rowCount, &tableType, &numBytes, &createTime, &updateTime); err != nil {
+		return nil, fmt.Errorf("failed to fetch table metadata: %w", err)
+	}
+	if rowCount.Valid && rowCount.Int64 > 0 {
+		metadata.RowCount = uint64(rowCount.Int64)
+	}
+	metadata.NumBytes = numBytes.Int64
+	metadata.CreationTime = createTime.Time
+	metadata.LastModifiedTime = updateTime.Time
+
+	if tableType == "VIEW" || tableType == "SYSTEM VIEW" {
+		metadata.Type = TableTypeView
+	} else {
+		metadata.Type = TableTypeTable
+	}
+
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COALESCE(COLUMN_COMMENT, '')
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, dataset, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch columns: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var column ColumnMetadata
+		var nullable string
+		if err := rows.Scan(&column.Name, &column.Type, &nullable, &column.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		column.Mode = ColumnModeNullable
+		if nullable == "NO" {
+			column.Mode = ColumnModeRequired
+		}
+		metadata.Columns = append(metadata.Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch columns: %w", err)
+	}
+
+	return metadata, nil
+}
+
+func (m *MySQLConnector) Close() error {
+	return m.db.Close()
+}
@@ -0,0 +1,164 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+)
+
+func TestMySQLConfig(t *testing.T) {
+	tests := []struct {
+		sslMode string
+		tls     string
+	}{
+		{"", "skip-verify"},
+		{"require", "skip-verify"},
+		{"verify-full", "true"},
+		{"prefer", "preferred"},
+		{"disable", ""},
+	}
+
+	for _, test := range tests {
+		config, err := newMySQLConfig(&ConnectionConfig{Host: "db.example.com", Database: "shop", SSLMode: test.sslMode})
+		if err != nil {
+			t.Fatalf("newMySQLConfig(%q): %v", test.sslMode, err)
+		}
+		if config.TLSConfig != test.tls || config.Addr != "db.example.com:3306" {
+			t.Errorf("newMySQLConfig(%q) = %s with TLS %q, want db.example.com:3306 with TLS %q", test.sslMode, config.Addr, config.TLSConfig, test.tls)
+		}
+	}
+
+	if _, err := newMySQLConfig(&ConnectionConfig{Host: "db.example.com", SSLMode: "allow"}); err == nil {
+		t.Errorf("newMySQLConfig accepted an unsupported sslmode")
+	}
+}
+
+// newTestMySQLConnector returns a connector to the server at TEST_MYSQL_URL,
+// a DSN such as root:secret@tcp(localhost:3306)/, and a new database that is
+// dropped after the test. The test is skipped if TEST_MYSQL_URL is not set.
+func newTestMySQLConnector(t *testing.T) (*MySQLConnector, string) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_MYSQL_URL")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_URL is not set")
+	}
+
+	// Timestamps are scanned like the connector's own connections do
+	config, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse TEST_MYSQL_URL: %v", err)
+	}
+	config.ParseTime = true
+
+	db, err := sql.Open("mysql", config.FormatDSN())
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+
+	schema := "katalog_test_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+	t.Cleanup(func() {
+		db.Exec("DROP DATABASE IF EXISTS " + schema)
+		db.Close()
+	})
+
+	return &MySQLConnector{db: db}, schema
+}
+
+func TestMySQLConnector(t *testing.T) {
+	conn, schema := newTestMySQLConnector(t)
+
+	for _, statement := range []string{
+		"CREATE DATABASE %s",
+		`CREATE TABLE %s.orders (
+			id int unsigned NOT NULL,
+			amount decimal(10,2) COMMENT 'Order total',
+			note varchar(50),
+			status enum('open','closed') NOT NULL,
+			created_at datetime
+		) ENGINE=InnoDB COMMENT='All orders'`,
+		"INSERT INTO %s.orders (id, status) VALUES (1, 'open'), (2, 'closed')",
+		"ANALYZE TABLE %s.orders",
+		"CREATE VIEW %s.open_orders AS SELECT id FROM %[1]s.orders WHERE status = 'open'",
+	} {
+		if _, err := conn.db.Exec(fmt.Sprintf(statement, schema)); err != nil {
+			t.Fatalf("failed to set up database: %s: %v", statement, err)
+		}
+	}
+
+	ctx := context.Background()
+
+	datasets, err := conn.ListDatasets(ctx)
+	if err != nil {
+		t.Fatalf("ListDatasets: %v", err)
+	}
+	var found bool
+	for _, dataset := range datasets {
+		switch dataset.Name {
+		case "mysql", "information_schema", "performance_schema", "sys":
+			t.Errorf("system schema %s was listed", dataset.Name)
+		case schema:
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("database %s was not listed", schema)
+	}
+
+	refs, err := conn.ListTables(ctx, schema)
+	if err != nil {
+		t.Fatalf("ListTables: %v", err)
+	}
+	if len(refs) != 2 || refs[0].Name != "open_orders" || refs[1].Name != "orders" {
+		t.Errorf("tables = %+v, want open_orders and orders", refs)
+	}
+
+	orders, err := conn.DescribeTable(ctx, schema, "orders")
+	if err != nil {
+		t.Fatalf("DescribeTable: %v", err)
+	}
+	if orders.Description != "All orders" || orders.Type != TableTypeTable || orders.RowCount != 2 || orders.CreationTime.IsZero() {
+		t.Errorf("orders = %q, %s, %d rows, created %v, want All orders, TABLE, 2 rows and a creation time",
+			orders.Description, orders.Type, orders.RowCount, orders.CreationTime)
+	}
+
+	// COLUMN_TYPE keeps lengths, precisions, enum values and unsigned, which
+	// DATA_TYPE drops. Integer display widths are only reported before 8.0.19.
+	wantColumns := []ColumnMetadata{
+		{Name: "id", Type: "int unsigned", Mode: ColumnModeRequired},
+		{Name: "amount", Type: "decimal(10,2)", Mode: ColumnModeNullable, Description: "Order total"},
+		{Name: "note", Type: "varchar(50)", Mode: ColumnModeNullable},
+		{Name: "status", Type: "enum('open','closed')", Mode: ColumnModeRequired},
+		{Name: "created_at", Type: "datetime", Mode: ColumnModeNullable},
+	}
+	if len(orders.Columns) != len(wantColumns) {
+		t.Fatalf("orders columns = %+v, want %+v", orders.Columns, wantColumns)
+	}
+	for i, want := range wantColumns {
+		got := orders.Columns[i]
+		if got.Type == "int(10) unsigned" {
+			got.Type = "int unsigned"
+		}
+		if got.Name != want.Name || got.Type != want.Type || got.Mode != want.Mode || got.Description != want.Description {
+			t.Errorf("column %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	view, err := conn.DescribeTable(ctx, schema, "open_orders")
+	if err != nil {
+		t.Fatalf("DescribeTable: %v", err)
+	}
+	if view.Type != TableTypeView || view.RowCount != 0 || len(view.Columns) != 1 {
+		t.Errorf("open_orders = %s with %d rows and %d columns, want a VIEW with no rows and 1 column", view.Type, view.RowCount, len(view.Columns))
+	}
+}
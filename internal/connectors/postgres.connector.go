@@ -0,0 +1,173 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+type PostgresConnector struct {
+	db *sql.DB
+}
+
+func NewPostgresConnector(config *ConnectionConfig) (*PostgresConnector, error) {
+	db, err := sql.Open("pgx", postgresDSN(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Postgres connection: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to Postgres: %w", err)
+	}
+
+	return &PostgresConnector{db: db}, nil
+}
+
+// postgresDSN returns the connection URL for config. Connections are
+// encrypted unless the config asks for another sslmode.
+func postgresDSN(config *ConnectionConfig) string {
+	port := config.Port
+	if port == 0 {
+		port = 5432
+	}
+	sslMode := config.SSLMode
+	if sslMode == "" {
+		sslMode = "require"
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     config.Host + ":" + strconv.Itoa(port),
+		Path:     config.Database,
+		RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+	}
+	return dsn.String()
+}
+
+func (p *PostgresConnector) ListDatasets(ctx context.Context) ([]DatasetMetadata, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT n.nspname, COALESCE(obj_description(n.oid, 'pg_namespace'), '')
+		FROM pg_catalog.pg_namespace n
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+			AND n.nspname NOT LIKE 'pg\_toast%'
+			AND n.nspname NOT LIKE 'pg\_temp\_%'
+		ORDER BY n.nspname`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var datasets []DatasetMetadata
+	for rows.Next() {
+		var dataset DatasetMetadata
+		if err := rows.Scan(&dataset.Name, &dataset.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan schema: %w", err)
+		}
+		datasets = append(datasets, dataset)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch schemas: %w", err)
+	}
+
+	return datasets, nil
+}
+
+func (p *PostgresConnector) ListTables(ctx context.Context, dataset string) ([]TableRef, error) {
+	// Tables, partitioned tables, views, materialized views and foreign tables.
+	// Individual partitions are skipped, they are cataloged through their parent.
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT c.relname
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+			AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+			AND NOT c.relispartition
+		ORDER BY c.relname`, dataset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableRef
+	for rows.Next() {
+		var table TableRef
+		if err := rows.Scan(&table.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %w", err)
+	}
+
+	return tables, nil
+}
+
+func (p *PostgresConnector) DescribeTable(ctx context.Context, dataset, table string) (*TableMetadata, error) {
+	metadata := &TableMetadata{Name: table}
+
+	// reltuples is an estimate maintained by VACUUM and ANALYZE, -1 if the
+	// table has never been analyzed.
+	var rowCount int64
+	var relKind string
+	if err := p.db.QueryRowContext(ctx, `
+		SELECT COALESCE(obj_description(c.oid, 'pg_class'), ''), GREATEST(c.reltuples, 0)::bigint,
+			c.relkind, pg_total_relation_size(c.oid)
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2`, dataset, table).Scan(&metadata.Description, &rowCount, &relKind, &metadata.NumBytes); err != nil {
+		return nil, fmt.Errorf("failed to fetch table metadata: %w", err)
+	}
+	metadata.RowCount = uint64(rowCount)
+
+	switch relKind {
+	case "v":
+		metadata.Type = TableTypeView
+	case "m":
+		metadata.Type = TableTypeMaterializedView
+	case "f":
+		metadata.Type = TableTypeExternal
+	default:
+		metadata.Type = TableTypeTable
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull, COALESCE(col_description(c.oid, a.attnum), '')
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, dataset, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch columns: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var column ColumnMetadata
+		var notNull bool
+		if err := rows.Scan(&column.Name, &column.Type, &notNull, &column.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		column.Mode = ColumnModeNullable
+		if notNull {
+			column.Mode = ColumnModeRequired
+		}
+		metadata.Columns = append(metadata.Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch columns: %w", err)
+	}
+
+	return metadata, nil
+}
+
+func (p *PostgresConnector) Close() error {
+	return p.db.Close()
+}
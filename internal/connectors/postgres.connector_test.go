@@ -0,0 +1,172 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPostgresDSN(t *testing.T) {
+	tests := []struct {
+		config  ConnectionConfig
+		host    string
+		sslMode string
+	}{
+		{ConnectionConfig{Host: "db.example.com", Database: "shop"}, "db.example.com:5432", "require"},
+		{ConnectionConfig{Host: "db.example.com", Port: 6432, Database: "shop", SSLMode: "verify-full"}, "db.example.com:6432", "verify-full"},
+		{ConnectionConfig{Host: "localhost", Database: "shop", SSLMode: "disable"}, "localhost:5432", "disable"},
+	}
+
+	for _, test := range tests {
+		dsn, err := url.Parse(postgresDSN(&test.config))
+		if err != nil {
+			t.Fatalf("postgresDSN(%+v) is not a URL: %v", test.config, err)
+		}
+		if dsn.Host != test.host || dsn.Query().Get("sslmode") != test.sslMode {
+			t.Errorf("postgresDSN(%+v) = %s, want host %s with sslmode %s", test.config, dsn, test.host, test.sslMode)
+		}
+	}
+}
+
+// newTestPostgresConnector returns a connector to the database at
+// TEST_DATABASE_URL and a new schema that is dropped after the test. The test
+// is skipped if TEST_DATABASE_URL is not set.
+func newTestPostgresConnector(t *testing.T) (*PostgresConnector, string) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+
+	schema := "katalog_test_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE")
+		db.Close()
+	})
+
+	return &PostgresConnector{db: db}, schema
+}
+
+func TestPostgresConnector(t *testing.T) {
+	conn, schema := newTestPostgresConnector(t)
+
+	for _, statement := range []string{
+		"CREATE SCHEMA %s",
+		"COMMENT ON SCHEMA %s IS 'Sales data'",
+		"CREATE TABLE %s.orders (id integer NOT NULL, amount numeric(10,2), note varchar(50), dropped text, created_at timestamp with time zone)",
+		"ALTER TABLE %s.orders DROP COLUMN dropped",
+		"COMMENT ON TABLE %s.orders IS 'All orders'",
+		"COMMENT ON COLUMN %s.orders.amount IS 'Order total'",
+		"INSERT INTO %s.orders (id) SELECT generate_series(1, 100)",
+		"ANALYZE %s.orders",
+		"CREATE INDEX ON %s.orders (id)",
+		"CREATE SEQUENCE %s.order_ids",
+		"CREATE TABLE %s.fresh (id integer)",
+		"CREATE VIEW %s.big_orders AS SELECT * FROM %[1]s.orders WHERE amount > 100",
+		"CREATE MATERIALIZED VIEW %s.order_totals AS SELECT sum(amount) AS total FROM %[1]s.orders",
+		"CREATE TABLE %s.events (id integer, day date) PARTITION BY RANGE (day)",
+		"CREATE TABLE %s.events_2024 PARTITION OF %[1]s.events FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+	} {
+		if _, err := conn.db.Exec(fmt.Sprintf(statement, schema)); err != nil {
+			t.Fatalf("failed to set up schema: %s: %v", statement, err)
+		}
+	}
+
+	ctx := context.Background()
+
+	datasets, err := conn.ListDatasets(ctx)
+	if err != nil {
+		t.Fatalf("ListDatasets: %v", err)
+	}
+	var found bool
+	for _, dataset := range datasets {
+		if dataset.Name == "pg_catalog" || dataset.Name == "information_schema" || strings.HasPrefix(dataset.Name, "pg_toast") {
+			t.Errorf("system schema %s was listed", dataset.Name)
+		}
+		if dataset.Name == schema {
+			found = true
+			if dataset.Description != "Sales data" {
+				t.Errorf("schema description = %q, want %q", dataset.Description, "Sales data")
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("schema %s was not listed", schema)
+	}
+
+	// Indexes, sequences and partitions are not cataloged on their own
+	refs, err := conn.ListTables(ctx, schema)
+	if err != nil {
+		t.Fatalf("ListTables: %v", err)
+	}
+	var names []string
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+	}
+	if got := strings.Join(names, ","); got != "big_orders,events,fresh,order_totals,orders" {
+		t.Errorf("tables = %s, want big_orders,events,fresh,order_totals,orders", got)
+	}
+
+	orders, err := conn.DescribeTable(ctx, schema, "orders")
+	if err != nil {
+		t.Fatalf("DescribeTable: %v", err)
+	}
+	if orders.Description != "All orders" || orders.Type != TableTypeTable || orders.RowCount != 100 || orders.NumBytes <= 0 {
+		t.Errorf("orders = %q, %s, %d rows, %d bytes, want All orders, TABLE, 100 rows and a size",
+			orders.Description, orders.Type, orders.RowCount, orders.NumBytes)
+	}
+	wantColumns := []ColumnMetadata{
+		{Name: "id", Type: "integer", Mode: ColumnModeRequired},
+		{Name: "amount", Type: "numeric(10,2)", Mode: ColumnModeNullable, Description: "Order total"},
+		{Name: "note", Type: "character varying(50)", Mode: ColumnModeNullable},
+		{Name: "created_at", Type: "timestamp with time zone", Mode: ColumnModeNullable},
+	}
+	if len(orders.Columns) != len(wantColumns) {
+		t.Fatalf("orders columns = %+v, want %+v", orders.Columns, wantColumns)
+	}
+	for i, want := range wantColumns {
+		got := orders.Columns[i]
+		if got.Name != want.Name || got.Type != want.Type || got.Mode != want.Mode || got.Description != want.Description {
+			t.Errorf("column %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	// A table that was never analyzed has no row estimate
+	fresh, err := conn.DescribeTable(ctx, schema, "fresh")
+	if err != nil {
+		t.Fatalf("DescribeTable: %v", err)
+	}
+	if fresh.RowCount != 0 {
+		t.Errorf("row count of a table never analyzed = %d, want 0", fresh.RowCount)
+	}
+
+	for table, want := range map[string]string{
+		"big_orders":   TableTypeView,
+		"order_totals": TableTypeMaterializedView,
+		"events":       TableTypeTable,
+	} {
+		metadata, err := conn.DescribeTable(ctx, schema, table)
+		if err != nil {
+			t.Fatalf("DescribeTable(%s): %v", table, err)
+		}
+		if metadata.Type != want {
+			t.Errorf("type of %s = %s, want %s", table, metadata.Type, want)
+		}
+	}
+}
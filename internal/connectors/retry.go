@@ -0,0 +1,62 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	retryAttempts  = 5
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// withRetry calls fn until it succeeds, returns an error that is not worth
+// retrying, or the attempts run out. Delays grow exponentially with jitter.
+func withRetry(ctx context.Context, fn func() error) error {
+	delay := retryBaseDelay
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt == retryAttempts || !isRetryable(err) {
+			return err
+		}
+
+		jitter := time.Duration(rand.Int63n(int64(delay) / 2))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay + jitter):
+		}
+
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
+
+// isRetryable reports whether err is a BigQuery rate limit or server error.
+func isRetryable(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if apiErr.Code == 429 || apiErr.Code >= 500 {
+		return true
+	}
+
+	for _, item := range apiErr.Errors {
+		if item.Reason == "rateLimitExceeded" || item.Reason == "backendError" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -5,6 +5,23 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// ChangelogOriginSource marks changes picked up from the source by a sync.
+	ChangelogOriginSource = "source"
+	// ChangelogOriginUser marks edits made in Katalog by a user.
+	ChangelogOriginUser = "user"
+)
+
+const (
+	// ChangeSeverityBreaking is a change that breaks existing consumers, such
+	// as a dropped column or a narrowed type.
+	ChangeSeverityBreaking = "breaking"
+	// ChangeSeverityPotentiallyBreaking is a change that breaks some
+	// consumers, such as a widened type or a new required column.
+	ChangeSeverityPotentiallyBreaking = "potentially_breaking"
+	ChangeSeveritySafe                = "safe"
+)
+
 type Changelog struct {
 	gorm.Model
 	ID              uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
@@ -20,4 +37,9 @@ type Changelog struct {
 	GrandParentID   *uuid.UUID `gorm:"type:uuid" json:"grandparent_id"`
 	GrandParentName string     `gorm:"type:varchar(255)" json:"grandparent_name"`
 	SyncID          *uuid.UUID `gorm:"type:uuid" json:"sync_id"`
+	ProjectID       *uuid.UUID `gorm:"type:uuid;index" json:"project_id"`
+	UserID          *uuid.UUID `gorm:"type:uuid" json:"user_id"`
+	Origin          string     `gorm:"type:varchar(50);not null;default:'source'" json:"origin"`
+	Severity        string     `gorm:"type:varchar(50);not null;default:'safe';index" json:"severity"`
+	PolicyViolation string     `gorm:"type:varchar(50)" json:"policy_violation"`
 }
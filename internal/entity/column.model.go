@@ -7,10 +7,18 @@ import (
 
 type Column struct {
 	gorm.Model
-	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
-	Name        string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_column_name_table" json:"name"`
-	Type        string    `gorm:"type:varchar(255);not null" json:"type"`
-	Description string    `gorm:"type:text" json:"description"`
-	TableID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_column_name_table" json:"table_id"`
-	ToDelete    bool      `gorm:"type:boolean" json:"to_delete"`
+	ID                 uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	Name               string     `gorm:"type:varchar(255);not null" json:"name"`
+	Path               string     `gorm:"type:text;not null;uniqueIndex:idx_column_path_table" json:"path"`
+	Depth              int        `gorm:"type:integer;not null;default:0" json:"depth"`
+	Position           int        `gorm:"type:integer;not null;default:0" json:"position"`
+	Type               string     `gorm:"type:varchar(255);not null" json:"type"`
+	Mode               string     `gorm:"type:varchar(50)" json:"mode"`
+	Description        string     `gorm:"type:text" json:"description"`
+	CuratedDescription string     `gorm:"type:text" json:"curated_description"`
+	Owner              string     `gorm:"type:text" json:"owner"`
+	Tags               string     `gorm:"type:text" json:"tags"`
+	TableID            uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_column_path_table" json:"table_id"`
+	ParentID           *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
+	ToDelete           bool       `gorm:"type:boolean" json:"to_delete"`
 }
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ColumnRenameStatusSuggested = "suggested"
+	ColumnRenameStatusConfirmed = "confirmed"
+	ColumnRenameStatusRejected  = "rejected"
+)
+
+// ColumnRename is a column that a sync detected as renamed. Curated metadata
+// is carried over from the old column when the rename is detected, and is
+// removed again if a user rejects the rename.
+type ColumnRename struct {
+	gorm.Model
+	ID                 uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ProjectID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"project_id"`
+	SyncID             uuid.UUID  `gorm:"type:uuid;not null" json:"sync_id"`
+	TableID            uuid.UUID  `gorm:"type:uuid;not null" json:"table_id"`
+	OldColumnID        uuid.UUID  `gorm:"type:uuid;not null" json:"old_column_id"`
+	NewColumnID        uuid.UUID  `gorm:"type:uuid;not null" json:"new_column_id"`
+	OldPath            string     `gorm:"type:text" json:"old_path"`
+	NewPath            string     `gorm:"type:text" json:"new_path"`
+	CarriedDescription string     `gorm:"type:text" json:"carried_description"`
+	Status             string     `gorm:"type:varchar(50);not null;default:'suggested';index" json:"status"`
+	ResolvedBy         *uuid.UUID `gorm:"type:uuid" json:"resolved_by"`
+	ResolvedAt         *time.Time `json:"resolved_at"`
+}
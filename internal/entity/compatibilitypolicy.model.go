@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// CompatibilityBackward requires that consumers on the new schema can
+	// read data written with the old one, e.g. no new required columns.
+	CompatibilityBackward = "backward"
+	// CompatibilityForward requires that consumers on the old schema can read
+	// data written with the new one, e.g. no dropped columns.
+	CompatibilityForward = "forward"
+	// CompatibilityFull requires both backward and forward compatibility.
+	CompatibilityFull = "full"
+)
+
+type CompatibilityPolicy struct {
+	gorm.Model
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;index" json:"project_id"`
+	DatasetID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"dataset_id"`
+	Level     string    `gorm:"type:varchar(50);not null" json:"level"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Connection struct {
+	gorm.Model
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	URL        string    `gorm:"type:text;not null"`
+	SourceType string    `gorm:"type:varchar(50);not null"`
+	ProjectID  uuid.UUID `gorm:"type:uuid;not null"`
+}
@@ -7,10 +7,13 @@ import (
 
 type Dataset struct {
 	gorm.Model
-	ID          uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
-	Name        string    `gorm:"type:varchar(100);uniqueIndex:idx_dataset_name_project" json:"name"`
-	Description string    `gorm:"type:text" json:"description"`
-	ProjectID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_dataset_name_project" json:"project_id"`
-	Tables      []Table   `gorm:"foreignKey:DatasetID" json:"tables"`
-	ToDelete    bool      `gorm:"type:boolean" json:"to_delete"`
+	ID                 uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	Name               string    `gorm:"type:varchar(100);uniqueIndex:idx_dataset_name_project" json:"name"`
+	Description        string    `gorm:"type:text" json:"description"`
+	CuratedDescription string    `gorm:"type:text" json:"curated_description"`
+	Owner              string    `gorm:"type:text" json:"owner"`
+	Tags               string    `gorm:"type:text" json:"tags"`
+	ProjectID          uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_dataset_name_project" json:"project_id"`
+	Tables             []Table   `gorm:"foreignKey:DatasetID" json:"tables"`
+	ToDelete           bool      `gorm:"type:boolean" json:"to_delete"`
 }
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DigestFrequencyDaily  = "daily"
+	DigestFrequencyWeekly = "weekly"
+)
+
+// DigestSubscription opts a user in to email digests of a project's changes.
+type DigestSubscription struct {
+	gorm.Model
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_digest_user_project" json:"user_id"`
+	ProjectID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_digest_user_project" json:"project_id"`
+	Frequency  string     `gorm:"type:varchar(50);not null" json:"frequency"`
+	LastSentAt *time.Time `json:"last_sent_at"`
+	RetryAt    *time.Time `json:"retry_at"`
+}
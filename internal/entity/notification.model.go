@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification tells a user that a sync changed an entity they watch.
+// EmailAt is when the notification is next due to be emailed, nil if it is
+// not to be emailed or no longer. EmailedAt is set once the email was sent.
+type Notification struct {
+	gorm.Model
+	ID            uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	ProjectID     uuid.UUID  `gorm:"type:uuid;not null" json:"project_id"`
+	WatchID       uuid.UUID  `gorm:"type:uuid;not null" json:"watch_id"`
+	SyncID        *uuid.UUID `gorm:"type:uuid" json:"sync_id"`
+	EntityType    string     `gorm:"type:varchar(50);not null" json:"entity_type"`
+	EntityID      uuid.UUID  `gorm:"type:uuid;not null" json:"entity_id"`
+	EntityName    string     `gorm:"type:varchar(255)" json:"entity_name"`
+	Message       string     `gorm:"type:text" json:"message"`
+	ChangeCount   int        `gorm:"type:integer;not null;default:0" json:"change_count"`
+	ReadAt        *time.Time `gorm:"index" json:"read_at"`
+	EmailAt       *time.Time `gorm:"index" json:"-"`
+	EmailAttempts int        `gorm:"type:integer;not null;default:0" json:"-"`
+	EmailedAt     *time.Time `json:"emailed_at"`
+}
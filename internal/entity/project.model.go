@@ -5,13 +5,30 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// DescriptionPrecedenceCurated shows the description written in Katalog,
+	// falling back to the source description if there is none.
+	DescriptionPrecedenceCurated = "curated"
+	// DescriptionPrecedenceSource shows the source description, falling back
+	// to the description written in Katalog if the source has none.
+	DescriptionPrecedenceSource = "source"
+)
+
+// Project is a source cataloged by a company. QueryModifiedTimes lets syncs
+// list the last modified times of a dataset's tables to skip unchanged ones,
+// for sources where that call is billed, like BigQuery.
 type Project struct {
 	gorm.Model
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
-	Name      string    `gorm:"type:varchar(100)" json:"name"`
-	Datasets  []Dataset `gorm:"foreignKey:ProjectID" json:"datasets"`
-	KeyFile   *KeyFile  `gorm:"foreignKey:ProjectID" json:"key_file"`
-	Syncs     []Sync    `gorm:"foreignKey:ProjectID" json:"syncs"`
-	CompanyID uuid.UUID `gorm:"type:uuid" json:"company_id"`
-	Company   Company   `gorm:"foreignKey:CompanyID" json:"company"`
+	ID                    uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	Name                  string    `gorm:"type:varchar(100)" json:"name"`
+	SourceType            string    `gorm:"type:varchar(50);not null;default:'bigquery'" json:"source_type"`
+	SyncConcurrency       int       `gorm:"type:integer;not null;default:4" json:"sync_concurrency"`
+	TolerateFailures      bool      `gorm:"type:boolean;not null;default:false" json:"tolerate_failures"`
+	QueryModifiedTimes    bool      `gorm:"type:boolean;not null;default:false" json:"query_modified_times"`
+	DescriptionPrecedence string    `gorm:"type:varchar(50);not null;default:'curated'" json:"description_precedence"`
+	Datasets              []Dataset `gorm:"foreignKey:ProjectID" json:"datasets"`
+	KeyFile               *KeyFile  `gorm:"foreignKey:ProjectID" json:"key_file"`
+	Syncs                 []Sync    `gorm:"foreignKey:ProjectID" json:"syncs"`
+	CompanyID             uuid.UUID `gorm:"type:uuid" json:"company_id"`
+	Company               Company   `gorm:"foreignKey:CompanyID" json:"company"`
 }
@@ -1,14 +1,54 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const (
+	SyncTriggerManual    = "manual"
+	SyncTriggerScheduled = "scheduled"
+)
+
+const (
+	SyncStatusRunning   = "running"
+	SyncStatusSucceeded = "succeeded"
+	// SyncStatusPartial is a sync that succeeded with warnings.
+	SyncStatusPartial = "partial"
+	SyncStatusFailed  = "failed"
+)
+
+// SyncStatusesSucceeded are the statuses of syncs whose changes were committed.
+var SyncStatusesSucceeded = []string{SyncStatusSucceeded, SyncStatusPartial}
+
 type Sync struct {
 	gorm.Model
-	ID          uuid.UUID   `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
-	ProjectID   *uuid.UUID  `gorm:"type:uuid;not null" json:"project_id"`
-	ChangelogID *uuid.UUID  `gorm:"type:uuid" json:"changelog_id"`
-	Changelogs  []Changelog `gorm:"foreignKey:SyncID" json:"changelogs"`
+	ID          uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ProjectID   *uuid.UUID    `gorm:"type:uuid;not null" json:"project_id"`
+	Trigger     string        `gorm:"type:varchar(50);not null;default:'manual'" json:"trigger"`
+	Status      string        `gorm:"type:varchar(50);not null;default:'succeeded';index" json:"status"`
+	Error       string        `gorm:"type:text" json:"error"`
+	TriggeredBy *uuid.UUID    `gorm:"type:uuid" json:"triggered_by"`
+	ScheduleID  *uuid.UUID    `gorm:"type:uuid" json:"schedule_id"`
+	StartedAt   *time.Time    `json:"started_at"`
+	FinishedAt  *time.Time    `json:"finished_at"`
+	Counts      SyncCounts    `gorm:"embedded" json:"counts"`
+	ChangelogID *uuid.UUID    `gorm:"type:uuid" json:"changelog_id"`
+	Changelogs  []Changelog   `gorm:"foreignKey:SyncID" json:"changelogs"`
+	Warnings    []SyncWarning `gorm:"foreignKey:SyncID" json:"warnings"`
+}
+
+// SyncCounts holds how many entities a sync read from the source and how
+// many of them ended up in its changelog.
+type SyncCounts struct {
+	DatasetsScanned  int `gorm:"not null;default:0" json:"datasets_scanned"`
+	TablesScanned    int `gorm:"not null;default:0" json:"tables_scanned"`
+	ColumnsScanned   int `gorm:"not null;default:0" json:"columns_scanned"`
+	DatasetsChanged  int `gorm:"not null;default:0" json:"datasets_changed"`
+	TablesChanged    int `gorm:"not null;default:0" json:"tables_changed"`
+	ColumnsChanged   int `gorm:"not null;default:0" json:"columns_changed"`
+	BreakingChanges  int `gorm:"not null;default:0" json:"breaking_changes"`
+	PolicyViolations int `gorm:"not null;default:0" json:"policy_violations"`
 }
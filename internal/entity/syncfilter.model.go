@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	SyncFilterActionInclude = "include"
+	SyncFilterActionExclude = "exclude"
+
+	SyncFilterPatternGlob  = "glob"
+	SyncFilterPatternRegex = "regex"
+)
+
+type SyncFilter struct {
+	gorm.Model
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ProjectID   uuid.UUID `gorm:"type:uuid;not null;index" json:"project_id"`
+	EntityType  string    `gorm:"type:varchar(50);not null" json:"entity_type"`
+	Action      string    `gorm:"type:varchar(50);not null" json:"action"`
+	PatternType string    `gorm:"type:varchar(50);not null" json:"pattern_type"`
+	Pattern     string    `gorm:"type:varchar(255);not null" json:"pattern"`
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	SyncJobStatusQueued    = "queued"
+	SyncJobStatusRunning   = "running"
+	SyncJobStatusSucceeded = "succeeded"
+	SyncJobStatusFailed    = "failed"
+)
+
+// SyncJob is a sync waiting for or being run by a worker. A running job's
+// HeartbeatAt is renewed by its worker, Attempts counts how often it was
+// claimed.
+type SyncJob struct {
+	gorm.Model
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ProjectID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"project_id"`
+	Status      string     `gorm:"type:varchar(50);not null;index" json:"status"`
+	Trigger     string     `gorm:"type:varchar(50);not null;default:'manual'" json:"trigger"`
+	Full        bool       `gorm:"type:boolean;not null;default:false" json:"full"`
+	DryRun      bool       `gorm:"type:boolean;not null;default:false" json:"dry_run"`
+	Result      string     `gorm:"type:text" json:"-"`
+	Error       string     `gorm:"type:text" json:"error"`
+	RequestedBy *uuid.UUID `gorm:"type:uuid" json:"requested_by"`
+	ScheduleID  *uuid.UUID `gorm:"type:uuid" json:"schedule_id"`
+	SyncID      *uuid.UUID `gorm:"type:uuid" json:"sync_id"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	StartedAt   *time.Time `json:"started_at"`
+	HeartbeatAt *time.Time `json:"heartbeat_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
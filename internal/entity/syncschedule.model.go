@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SyncSchedule struct {
+	gorm.Model
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ProjectID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"project_id"`
+	CronExpression string     `gorm:"type:varchar(100);not null" json:"cron_expression"`
+	Timezone       string     `gorm:"type:varchar(100);not null" json:"timezone"`
+	Enabled        bool       `gorm:"type:boolean;not null" json:"enabled"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	NextRunAt      *time.Time `gorm:"index" json:"next_run_at"`
+}
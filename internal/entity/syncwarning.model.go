@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SyncWarning is a table that could not be described during a sync, or a
+// dataset whose tables could not be listed during a sync that tolerates
+// failures. The entity keeps its state from the previous sync. A dataset
+// whose last modified times could not be listed is synced in full instead.
+type SyncWarning struct {
+	gorm.Model
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	SyncID     *uuid.UUID `gorm:"type:uuid;index" json:"sync_id"`
+	EntityType string     `gorm:"type:varchar(100)" json:"entity_type"`
+	EntityName string     `gorm:"type:varchar(255)" json:"entity_name"`
+	Message    string     `gorm:"type:text" json:"message"`
+}
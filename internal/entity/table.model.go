@@ -1,17 +1,33 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Table struct {
 	gorm.Model
-	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
-	Name        string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_table_name_dataset" json:"name"`
-	DatasetID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_table_name_dataset" json:"dataset_id"`
-	Description string    `gorm:"type:text" json:"description"`
-	RowCount    uint64    `gorm:"type:bigint" json:"row_count"`
-	Columns     []Column  `gorm:"foreignKey:TableID" json:"columns"`
-	ToDelete    bool      `gorm:"type:boolean" json:"to_delete"`
+	ID                 uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	Name               string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_table_name_dataset" json:"name"`
+	DatasetID          uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_table_name_dataset" json:"dataset_id"`
+	Description        string     `gorm:"type:text" json:"description"`
+	CuratedDescription string     `gorm:"type:text" json:"curated_description"`
+	Owner              string     `gorm:"type:text" json:"owner"`
+	Tags               string     `gorm:"type:text" json:"tags"`
+	Type               string     `gorm:"type:varchar(50)" json:"type"`
+	RowCount           uint64     `gorm:"type:bigint" json:"row_count"`
+	NumBytes           int64      `gorm:"type:bigint" json:"num_bytes"`
+	Location           string     `gorm:"type:varchar(100)" json:"location"`
+	TimePartitioning   string     `gorm:"type:text" json:"time_partitioning"`
+	RangePartitioning  string     `gorm:"type:text" json:"range_partitioning"`
+	Clustering         string     `gorm:"type:text" json:"clustering"`
+	Labels             string     `gorm:"type:text" json:"labels"`
+	ExpirationTime     *time.Time `json:"expiration_time"`
+	CreationTime       *time.Time `json:"creation_time"`
+	LastModifiedTime   *time.Time `json:"last_modified_time"`
+	SchemaFingerprint  string     `gorm:"type:varchar(64)" json:"schema_fingerprint"`
+	Columns            []Column   `gorm:"foreignKey:TableID" json:"columns"`
+	ToDelete           bool       `gorm:"type:boolean" json:"to_delete"`
 }
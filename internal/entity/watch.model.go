@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Watch subscribes a user to the changes of a dataset, table or column and
+// everything below it.
+type Watch struct {
+	gorm.Model
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_watch_user_entity" json:"user_id"`
+	ProjectID  uuid.UUID `gorm:"type:uuid;not null;index" json:"project_id"`
+	EntityType string    `gorm:"type:varchar(50);not null" json:"entity_type"`
+	EntityID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_watch_user_entity;index" json:"entity_id"`
+	EntityName string    `gorm:"type:varchar(255)" json:"entity_name"`
+	Email      bool      `gorm:"type:boolean;not null;default:false" json:"email"`
+}
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookEventSyncCompleted  = "sync.completed"
+	WebhookEventSyncFailed     = "sync.failed"
+	WebhookEventBreakingChange = "schema.breaking_change"
+	WebhookEventEntityDeleted  = "entity.deleted"
+)
+
+// Webhook posts events of a project to a URL. Events is a comma separated
+// list of the events it receives.
+type Webhook struct {
+	gorm.Model
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ProjectID uuid.UUID  `gorm:"type:uuid;not null;index" json:"project_id"`
+	URL       string     `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret    string     `gorm:"type:varchar(255);not null" json:"-"`
+	Events    string     `gorm:"type:varchar(255);not null" json:"-"`
+	Enabled   bool       `gorm:"type:boolean;not null;default:true" json:"enabled"`
+	CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+}
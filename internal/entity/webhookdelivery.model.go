@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+type WebhookDelivery struct {
+	gorm.Model
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	WebhookID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	Event          string     `gorm:"type:varchar(100);not null" json:"event"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(50);not null;index" json:"status"`
+	Attempts       int        `gorm:"type:integer;not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"index" json:"next_attempt_at"`
+	ResponseStatus int        `gorm:"type:integer" json:"response_status"`
+	Error          string     `gorm:"type:text" json:"error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	RedeliveryOf   *uuid.UUID `gorm:"type:uuid" json:"redelivery_of"`
+}
@@ -139,10 +139,10 @@ func GetDashboardStatistics(ctx *appcontext.Context) gin.HandlerFunc {
 		}
 
 		var currentMonthSyncCount int64
-		ctx.DB.Model(&entity.Sync{}).Where("project_id = ? AND created_at >= ?", projectID, currentMonthStart).Count(&currentMonthSyncCount)
+		ctx.DB.Model(&entity.Sync{}).Where("project_id = ? AND status IN ? AND created_at >= ?", projectID, entity.SyncStatusesSucceeded, currentMonthStart).Count(&currentMonthSyncCount)
 
 		var pastMonthSyncCount int64
-		ctx.DB.Model(&entity.Sync{}).Where("project_id = ? AND created_at >= ? AND created_at < ?", projectID, pastMonthStart, currentMonthStart).Count(&pastMonthSyncCount)
+		ctx.DB.Model(&entity.Sync{}).Where("project_id = ? AND status IN ? AND created_at >= ? AND created_at < ?", projectID, entity.SyncStatusesSucceeded, pastMonthStart, currentMonthStart).Count(&pastMonthSyncCount)
 
 		var currentMonthChangeCountsRaw []struct {
 			ChangeType string
@@ -156,9 +156,12 @@ func GetDashboardStatistics(ctx *appcontext.Context) gin.HandlerFunc {
 			Scan(&currentMonthChangeCountsRaw)
 
 		currentMonthChangeCountsResponse := struct {
-			Insert int64 `json:"insert"`
-			Update int64 `json:"update"`
-			Delete int64 `json:"delete"`
+			Insert  int64 `json:"insert"`
+			Update  int64 `json:"update"`
+			Delete  int64 `json:"delete"`
+			Exclude int64 `json:"exclude"`
+			Restore int64 `json:"restore"`
+			Rename  int64 `json:"rename"`
 		}{}
 
 		for _, item := range currentMonthChangeCountsRaw {
@@ -169,26 +172,68 @@ func GetDashboardStatistics(ctx *appcontext.Context) gin.HandlerFunc {
 				currentMonthChangeCountsResponse.Update = item.Count
 			case "delete":
 				currentMonthChangeCountsResponse.Delete = item.Count
+			case "exclude":
+				currentMonthChangeCountsResponse.Exclude = item.Count
+			case "restore":
+				currentMonthChangeCountsResponse.Restore = item.Count
+			case "rename":
+				currentMonthChangeCountsResponse.Rename = item.Count
 			}
 		}
 
+		var currentMonthSeverityCountsRaw []struct {
+			Severity string
+			Count    int64
+		}
+		ctx.DB.Model(&entity.Changelog{}).
+			Select("changelogs.severity, COUNT(*) as count").
+			Joins("JOIN syncs ON syncs.id = changelogs.sync_id").
+			Where("syncs.project_id = ? AND changelogs.created_at >= ? AND changelogs.deleted_at IS NULL", projectID, currentMonthStart).
+			Group("changelogs.severity").
+			Scan(&currentMonthSeverityCountsRaw)
+
+		currentMonthSeverityCountsResponse := struct {
+			Breaking            int64 `json:"breaking"`
+			PotentiallyBreaking int64 `json:"potentiallyBreaking"`
+			Safe                int64 `json:"safe"`
+		}{}
+
+		for _, item := range currentMonthSeverityCountsRaw {
+			switch item.Severity {
+			case entity.ChangeSeverityBreaking:
+				currentMonthSeverityCountsResponse.Breaking = item.Count
+			case entity.ChangeSeverityPotentiallyBreaking:
+				currentMonthSeverityCountsResponse.PotentiallyBreaking = item.Count
+			case entity.ChangeSeveritySafe:
+				currentMonthSeverityCountsResponse.Safe = item.Count
+			}
+		}
+
+		var currentMonthPolicyViolationCount int64
+		ctx.DB.Model(&entity.Changelog{}).
+			Joins("JOIN syncs ON syncs.id = changelogs.sync_id").
+			Where("syncs.project_id = ? AND changelogs.created_at >= ? AND changelogs.policy_violation <> ''", projectID, currentMonthStart).
+			Count(&currentMonthPolicyViolationCount)
+
 		// Prepare the response structure
 		response := gin.H{
-			"userHasSync":              true,
-			"totalDatasetCount":        totalDatasetCount,
-			"totalTableCount":          totalTableCount,
-			"totalColumnCount":         totalColumnCount,
-			"totalRowCount":            totalRowCount,
-			"pastMonthDatasetCount":    pastMonthDatasetCount,
-			"pastMonthTableCount":      pastMonthTableCount,
-			"pastMonthColumnCount":     pastMonthColumnCount,
-			"pastMonthTotalRowCount":   pastMonthTotalRowCount,
-			"tableCounts":              tableCountsResponse,
-			"tableSizeMetric":          tableSizeMetricResponse,
-			"columnTypeDistribution":   columnTypeDistributionResponse,
-			"currentMonthSyncCount":    currentMonthSyncCount,
-			"pastMonthSyncCount":       pastMonthSyncCount,
-			"currentMonthChangeCounts": currentMonthChangeCountsResponse,
+			"userHasSync":                      true,
+			"totalDatasetCount":                totalDatasetCount,
+			"totalTableCount":                  totalTableCount,
+			"totalColumnCount":                 totalColumnCount,
+			"totalRowCount":                    totalRowCount,
+			"pastMonthDatasetCount":            pastMonthDatasetCount,
+			"pastMonthTableCount":              pastMonthTableCount,
+			"pastMonthColumnCount":             pastMonthColumnCount,
+			"pastMonthTotalRowCount":           pastMonthTotalRowCount,
+			"tableCounts":                      tableCountsResponse,
+			"tableSizeMetric":                  tableSizeMetricResponse,
+			"columnTypeDistribution":           columnTypeDistributionResponse,
+			"currentMonthSyncCount":            currentMonthSyncCount,
+			"pastMonthSyncCount":               pastMonthSyncCount,
+			"currentMonthChangeCounts":         currentMonthChangeCountsResponse,
+			"currentMonthSeverityCounts":       currentMonthSeverityCountsResponse,
+			"currentMonthPolicyViolationCount": currentMonthPolicyViolationCount,
 		}
 
 		// Send the response
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestDashboardStatisticsExcludeFilteredEntities(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", connectorTable("orders", 100, "id", "amount"))
+	conn.AddTable("sales", connectorTable("orders_backup", 100, "id", "amount"))
+	conn.AddTable("tmp_scratch", connectorTable("scratch", 5, "id"))
+
+	sync := func() {
+		t.Helper()
+		run, err := services.StartSync(ctx.DB, project.ID, services.SyncOptions{Trigger: entity.SyncTriggerManual})
+		if err != nil {
+			t.Fatalf("StartSync: %v", err)
+		}
+		if _, err := services.SyncProject(ctx, context.Background(), project.ID, run, conn, services.SyncOptions{Trigger: entity.SyncTriggerManual}); err != nil {
+			t.Fatalf("SyncProject: %v", err)
+		}
+	}
+	sync()
+
+	for _, filter := range []entity.SyncFilter{
+		{ProjectID: project.ID, EntityType: "dataset", Action: entity.SyncFilterActionExclude, PatternType: entity.SyncFilterPatternGlob, Pattern: "tmp_*"},
+		{ProjectID: project.ID, EntityType: "table", Action: entity.SyncFilterActionExclude, PatternType: entity.SyncFilterPatternGlob, Pattern: "*_backup"},
+	} {
+		if err := ctx.DB.Create(&filter).Error; err != nil {
+			t.Fatalf("failed to create filter: %v", err)
+		}
+	}
+	sync()
+
+	var stats struct {
+		TotalDatasetCount int64 `json:"totalDatasetCount"`
+		TotalTableCount   int64 `json:"totalTableCount"`
+		TotalColumnCount  int64 `json:"totalColumnCount"`
+		TotalRowCount     int64 `json:"totalRowCount"`
+		TableCounts       struct {
+			DatasetNames []string `json:"datasetNames"`
+			TableCounts  []int64  `json:"tableCounts"`
+		} `json:"tableCounts"`
+		ColumnTypeDistribution []struct {
+			Label string `json:"label"`
+			Value int64  `json:"value"`
+		} `json:"columnTypeDistribution"`
+		CurrentMonthChangeCounts struct {
+			Exclude int64 `json:"exclude"`
+		} `json:"currentMonthChangeCounts"`
+	}
+	if code := serve(t, ctx, http.MethodGet, "/api/v1/analytics/"+project.ID.String()+"/dashboard", user.ID, nil, &stats); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+
+	if stats.TotalDatasetCount != 1 || stats.TotalTableCount != 1 || stats.TotalColumnCount != 2 || stats.TotalRowCount != 100 {
+		t.Errorf("totals = %d datasets, %d tables, %d columns, %d rows, want 1, 1, 2, 100",
+			stats.TotalDatasetCount, stats.TotalTableCount, stats.TotalColumnCount, stats.TotalRowCount)
+	}
+	if len(stats.TableCounts.DatasetNames) != 1 || stats.TableCounts.DatasetNames[0] != "sales" || stats.TableCounts.TableCounts[0] != 1 {
+		t.Errorf("table counts = %+v, want 1 table in sales", stats.TableCounts)
+	}
+	if len(stats.ColumnTypeDistribution) != 1 || stats.ColumnTypeDistribution[0].Value != 2 {
+		t.Errorf("column types = %+v, want 2 STRING columns", stats.ColumnTypeDistribution)
+	}
+	if stats.CurrentMonthChangeCounts.Exclude != 2 {
+		t.Errorf("excluded changes = %d, want 2", stats.CurrentMonthChangeCounts.Exclude)
+	}
+}
+
+func TestDashboardStatisticsCountSucceededSyncs(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	failed, err := services.StartSync(ctx.DB, project.ID, services.SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil {
+		t.Fatalf("StartSync: %v", err)
+	}
+	if err := services.FinishSync(ctx.DB, failed, nil, errors.New("permission denied")); err != nil {
+		t.Fatalf("FinishSync: %v", err)
+	}
+	if _, err := services.StartSync(ctx.DB, project.ID, services.SyncOptions{Trigger: entity.SyncTriggerManual}); err != nil {
+		t.Fatalf("StartSync: %v", err)
+	}
+
+	var stats struct {
+		UserHasSync           *bool `json:"userHasSync"`
+		CurrentMonthSyncCount int64 `json:"currentMonthSyncCount"`
+	}
+	target := "/api/v1/analytics/" + project.ID.String() + "/dashboard"
+	if code := serve(t, ctx, http.MethodGet, target, user.ID, nil, &stats); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if stats.UserHasSync == nil || *stats.UserHasSync {
+		t.Errorf("userHasSync = %v, want false without a successful sync", stats.UserHasSync)
+	}
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", connectorTable("orders", 100, "id"))
+	run, err := services.StartSync(ctx.DB, project.ID, services.SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil {
+		t.Fatalf("StartSync: %v", err)
+	}
+	if _, err := services.SyncProject(ctx, context.Background(), project.ID, run, conn, services.SyncOptions{Trigger: entity.SyncTriggerManual}); err != nil {
+		t.Fatalf("SyncProject: %v", err)
+	}
+
+	if code := serve(t, ctx, http.MethodGet, target, user.ID, nil, &stats); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if !*stats.UserHasSync || stats.CurrentMonthSyncCount != 1 {
+		t.Errorf("userHasSync = %v with %d syncs this month, want true with 1", *stats.UserHasSync, stats.CurrentMonthSyncCount)
+	}
+}
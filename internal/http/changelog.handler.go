@@ -0,0 +1,159 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// QueryChangelogs lists the changelog of a project, newest first, filtered by
+// the query parameters:
+//
+//   - entity_type, change_type, field, severity, origin: exact matches
+//   - entity_id: the entity itself
+//   - entity_name: the entity name, optionally qualified by its parents,
+//     e.g. orders.customer_id
+//   - parent_id, parent_name: changes below a dataset or table
+//   - since, until: RFC 3339 timestamps
+//
+// Pages are requested with limit and the next_cursor of the previous page.
+func QueryChangelogs(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		query, err := filterChangelogs(c, ctx.DB.Where("project_id = ?", projectID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		respondWithChangelogPage(ctx, c, query)
+	}
+}
+
+// GetEntityHistory lists every change to a dataset, table or column, newest
+// first. With include_children=true, changes to the tables and columns below
+// the entity are included.
+func GetEntityHistory(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		entityID := c.Param("entityID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		query := ctx.DB.Where("project_id = ?", projectID)
+		if c.Query("include_children") == "true" {
+			query = query.Where("(entity_id = ? OR parent_id = ? OR grand_parent_id = ?)", entityID, entityID, entityID)
+		} else {
+			query = query.Where("entity_id = ?", entityID)
+		}
+
+		query, err = filterChangelogs(c, query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		respondWithChangelogPage(ctx, c, query)
+	}
+}
+
+func filterChangelogs(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	exactFilters := map[string]string{
+		"entity_type": "entity_type",
+		"change_type": "change_type",
+		"field":       "field_name",
+		"severity":    "severity",
+		"origin":      "origin",
+	}
+	for param, column := range exactFilters {
+		if value := c.Query(param); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if entityName := c.Query("entity_name"); entityName != "" {
+		query = query.Where("(entity_name = ? OR parent_name || '.' || entity_name = ? OR grand_parent_name || '.' || parent_name || '.' || entity_name = ?)", entityName, entityName, entityName)
+	}
+	if parentID := c.Query("parent_id"); parentID != "" {
+		query = query.Where("(parent_id = ? OR grand_parent_id = ?)", parentID, parentID)
+	}
+	if parentName := c.Query("parent_name"); parentName != "" {
+		query = query.Where("(parent_name = ? OR grand_parent_name = ? OR grand_parent_name || '.' || parent_name = ?)", parentName, parentName, parentName)
+	}
+
+	for param, condition := range map[string]string{"since": "created_at >= ?", "until": "created_at < ?"} {
+		if value := c.Query(param); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where(condition, at)
+		}
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	return query, nil
+}
+
+func respondWithChangelogPage(ctx *appcontext.Context, c *gin.Context, query *gorm.DB) {
+	limit := limitParam(c)
+
+	// One more entry than requested tells whether there is a next page
+	var changelogs []entity.Changelog
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&changelogs).Error; err != nil {
+		ctx.Logger.Error("Failed to get changelogs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get changelogs"})
+		return
+	}
+
+	var nextCursor string
+	if len(changelogs) > limit {
+		changelogs = changelogs[:limit]
+		last := changelogs[limit-1]
+		nextCursor = encodeCursor(changelogCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changelogs": changelogs, "next_cursor": nextCursor})
+}
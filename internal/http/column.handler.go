@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kerem-kaynak/katalog/internal/appcontext"
 	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
 	"github.com/kerem-kaynak/katalog/internal/utils"
 	"go.uber.org/zap"
 )
@@ -23,29 +24,126 @@ func GetColumnsByTableID(ctx *appcontext.Context) gin.HandlerFunc {
 		}
 
 		userHasAccess := utils.UserHasTableAccess(ctx, userID, uuid.MustParse(tableID))
+		if isPointInTime(c) {
+			userHasAccess = utils.UserHasTableHistoryAccess(ctx, userID, uuid.MustParse(tableID))
+		}
 		if !userHasAccess {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
 			return
 		}
 
+		var table entity.Table
+		if err := ctx.DB.Unscoped().Where("id = ?", tableID).First(&table).Error; err != nil {
+			ctx.Logger.Error("Failed to get table", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get columns"})
+			return
+		}
+
+		var dataset entity.Dataset
+		if err := ctx.DB.Unscoped().Where("id = ?", table.DatasetID).First(&dataset).Error; err != nil {
+			ctx.Logger.Error("Failed to get dataset", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get columns"})
+			return
+		}
+
+		at, err := pointInTime(ctx, c, dataset.ProjectID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		var columns []entity.Column
-		if err := ctx.DB.Where("table_id = ?", tableID).Find(&columns).Error; err != nil {
+		if at != nil {
+			columns, err = services.TableStateAt(ctx.DB, table.ID, *at)
+			if err != nil {
+				ctx.Logger.Error("Failed to reconstruct columns", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get columns"})
+				return
+			}
+		} else if err := ctx.DB.Where("table_id = ?", tableID).Order("path").Find(&columns).Error; err != nil {
 			ctx.Logger.Error("Failed to get columns", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get columns"})
 			return
 		}
 
+		precedence, err := utils.TableDescriptionPrecedence(ctx.DB, uuid.MustParse(tableID))
+		if err != nil {
+			ctx.Logger.Error("Failed to get description precedence", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get columns"})
+			return
+		}
+
 		var response []map[string]interface{}
 		for _, column := range columns {
-			response = append(response, map[string]interface{}{
-				"id":          column.ID,
-				"name":        column.Name,
-				"description": column.Description,
-				"table_id":    column.TableID,
-				"type":        column.Type,
-			})
+			response = append(response, columnResponse(&column, precedence))
 		}
 
 		c.JSON(http.StatusOK, gin.H{"columns": response})
 	}
 }
+
+func UpdateColumn(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		columnID := c.Param("columnID")
+
+		var request curatedFieldsRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var column entity.Column
+		if err := ctx.DB.Where("id = ?", columnID).First(&column).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Column not found"})
+			return
+		}
+
+		userHasAccess := utils.UserHasTableAccess(ctx, userID, column.TableID)
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		updated, err := services.EditColumn(ctx, userID, column.ID, request.fields())
+		if err != nil {
+			ctx.Logger.Error("Failed to update column", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update column"})
+			return
+		}
+
+		precedence, err := utils.TableDescriptionPrecedence(ctx.DB, updated.TableID)
+		if err != nil {
+			ctx.Logger.Error("Failed to get description precedence", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update column"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"column": columnResponse(updated, precedence)})
+	}
+}
+
+func columnResponse(column *entity.Column, precedence string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                  column.ID,
+		"name":                column.Name,
+		"path":                column.Path,
+		"depth":               column.Depth,
+		"parent_id":           column.ParentID,
+		"description":         utils.EffectiveDescription(precedence, column.Description, column.CuratedDescription),
+		"source_description":  column.Description,
+		"curated_description": column.CuratedDescription,
+		"owner":               column.Owner,
+		"tags":                rawJSON(column.Tags),
+		"table_id":            column.TableID,
+		"type":                column.Type,
+		"mode":                column.Mode,
+	}
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/connectors"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+)
+
+func CreateConnection(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		type createConnectionRequest struct {
+			SourceType string `json:"sourceType" binding:"required"`
+			connectors.ConnectionConfig
+		}
+
+		var request createConnectionRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		if request.SourceType != connectors.SourceTypePostgres && request.SourceType != connectors.SourceTypeMySQL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported source type"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		connectionBytes, err := json.Marshal(request.ConnectionConfig)
+		if err != nil {
+			ctx.Logger.Error("Failed to marshal connection", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal connection"})
+			return
+		}
+
+		bucketName := ctx.GCSBucketName
+		objectPath := projectID + "/" + "connection"
+
+		w := ctx.GCSClient.Bucket(bucketName).Object(objectPath).NewWriter(context.Background())
+
+		if _, err := w.Write(connectionBytes); err != nil {
+			ctx.Logger.Error("Failed to upload connection to GCS", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload connection to GCS"})
+			return
+		}
+
+		if err := w.Close(); err != nil {
+			ctx.Logger.Error("Failed to close GCS writer", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close GCS writer"})
+			return
+		}
+
+		connection := entity.Connection{
+			ProjectID:  uuid.MustParse(projectID),
+			SourceType: request.SourceType,
+			URL:        "https://storage.googleapis.com/" + bucketName + "/" + objectPath,
+		}
+
+		if err := ctx.DB.Where("project_id = ?", projectID).Delete(&entity.Connection{}).Error; err != nil {
+			ctx.Logger.Error("Failed to delete existing connection", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete existing connection"})
+			return
+		}
+
+		if err := ctx.DB.Create(&connection).Error; err != nil {
+			ctx.Logger.Error("Failed to store connection in database", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store connection in database"})
+			return
+		}
+
+		if err := ctx.DB.Model(&entity.Project{}).Where("id = ?", projectID).Update("source_type", request.SourceType).Error; err != nil {
+			ctx.Logger.Error("Failed to update project source type", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project source type"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Connection stored successfully"})
+	}
+}
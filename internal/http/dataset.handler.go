@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kerem-kaynak/katalog/internal/appcontext"
 	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
 	"github.com/kerem-kaynak/katalog/internal/utils"
 	"go.uber.org/zap"
 )
@@ -35,23 +36,119 @@ func GetDatasets(ctx *appcontext.Context) gin.HandlerFunc {
 			return
 		}
 
+		at, err := pointInTime(ctx, c, uuid.MustParse(projectID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		var datasets []entity.Dataset
-		if err := ctx.DB.Preload("Tables").Where("project_id = ?", projectID).Find(&datasets).Error; err != nil {
+		if at != nil {
+			datasets, err = services.StateAt(ctx.DB, uuid.MustParse(projectID), *at)
+			if err != nil {
+				ctx.Logger.Error("Failed to reconstruct datasets", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasets"})
+				return
+			}
+		} else if err := ctx.DB.Preload("Tables").Where("project_id = ?", projectID).Find(&datasets).Error; err != nil {
 			ctx.Logger.Error("Failed to fetch datasets", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasets"})
 			return
 		}
 
+		var project entity.Project
+		if err := ctx.DB.Where("id = ?", projectID).First(&project).Error; err != nil {
+			ctx.Logger.Error("Failed to get project", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+			return
+		}
+
 		var response []map[string]interface{}
 		for _, dataset := range datasets {
-			response = append(response, map[string]interface{}{
-				"id":          dataset.ID,
-				"name":        dataset.Name,
-				"description": dataset.Description,
-				"table_count": len(dataset.Tables),
-			})
+			response = append(response, datasetResponse(&dataset, project.DescriptionPrecedence))
 		}
 
 		c.JSON(http.StatusOK, gin.H{"datasets": response})
 	}
 }
+
+func UpdateDataset(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		datasetID := c.Param("datasetID")
+
+		var request curatedFieldsRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasDatasetAccess(ctx, userID, uuid.MustParse(datasetID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		dataset, err := services.EditDataset(ctx, userID, uuid.MustParse(datasetID), request.fields())
+		if err != nil {
+			ctx.Logger.Error("Failed to update dataset", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dataset"})
+			return
+		}
+
+		precedence, err := utils.DatasetDescriptionPrecedence(ctx.DB, dataset.ID)
+		if err != nil {
+			ctx.Logger.Error("Failed to get description precedence", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dataset"})
+			return
+		}
+
+		var tableCount int64
+		if err := ctx.DB.Model(&entity.Table{}).Where("dataset_id = ?", dataset.ID).Count(&tableCount).Error; err != nil {
+			ctx.Logger.Error("Failed to count tables", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dataset"})
+			return
+		}
+
+		response := datasetResponse(dataset, precedence)
+		response["table_count"] = tableCount
+
+		c.JSON(http.StatusOK, gin.H{"dataset": response})
+	}
+}
+
+func datasetResponse(dataset *entity.Dataset, precedence string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                  dataset.ID,
+		"name":                dataset.Name,
+		"description":         utils.EffectiveDescription(precedence, dataset.Description, dataset.CuratedDescription),
+		"source_description":  dataset.Description,
+		"curated_description": dataset.CuratedDescription,
+		"owner":               dataset.Owner,
+		"tags":                rawJSON(dataset.Tags),
+		"table_count":         len(dataset.Tables),
+	}
+}
+
+// curatedFieldsRequest is the body of the PATCH endpoints for datasets,
+// tables and columns. Omitted fields are left unchanged.
+type curatedFieldsRequest struct {
+	CuratedDescription *string   `json:"curatedDescription"`
+	Owner              *string   `json:"owner"`
+	Tags               *[]string `json:"tags"`
+}
+
+func (r curatedFieldsRequest) fields() services.CuratedFields {
+	return services.CuratedFields{
+		CuratedDescription: r.CuratedDescription,
+		Owner:              r.Owner,
+		Tags:               r.Tags,
+	}
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GetDigestSubscription returns the current user's digest subscription for
+// the project, or null if they are not subscribed.
+func GetDigestSubscription(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var subscription entity.DigestSubscription
+		if err := ctx.DB.Where("user_id = ? AND project_id = ?", userID, projectID).First(&subscription).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusOK, gin.H{"subscription": nil})
+				return
+			}
+			ctx.Logger.Error("Failed to get digest subscription", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get digest subscription"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"subscription": subscription})
+	}
+}
+
+// UpsertDigestSubscription opts the current user in to daily or weekly
+// digests of the project, or changes the frequency.
+func UpsertDigestSubscription(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		type upsertDigestRequest struct {
+			Frequency string `json:"frequency" binding:"required"`
+		}
+
+		var request upsertDigestRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		if !services.ValidDigestFrequency(request.Frequency) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "frequency must be daily or weekly"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var subscription entity.DigestSubscription
+		err = ctx.DB.Where("user_id = ? AND project_id = ?", userID, projectID).First(&subscription).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.Logger.Error("Failed to get digest subscription", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get digest subscription"})
+			return
+		}
+
+		// The first digest covers the changes from the time of subscribing
+		if subscription.LastSentAt == nil {
+			now := time.Now()
+			subscription.LastSentAt = &now
+		}
+		subscription.UserID = userID
+		subscription.ProjectID = uuid.MustParse(projectID)
+		subscription.Frequency = request.Frequency
+
+		if err := ctx.DB.Save(&subscription).Error; err != nil {
+			ctx.Logger.Error("Failed to save digest subscription", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save digest subscription"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"subscription": subscription})
+	}
+}
+
+// DeleteDigestSubscription opts the current user out of the project's
+// digests.
+func DeleteDigestSubscription(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		if err := ctx.DB.Unscoped().Where("user_id = ? AND project_id = ?", userID, projectID).Delete(&entity.DigestSubscription{}).Error; err != nil {
+			ctx.Logger.Error("Failed to delete digest subscription", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete digest subscription"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed from digest"})
+	}
+}
+
+// PreviewDigest returns the digest the project would get for the given
+// frequency if it were sent now.
+func PreviewDigest(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		frequency := c.DefaultQuery("frequency", entity.DigestFrequencyDaily)
+		if !services.ValidDigestFrequency(frequency) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "frequency must be daily or weekly"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		now := time.Now()
+		digest, err := services.BuildDigest(ctx.DB, uuid.MustParse(projectID), now.Add(-services.DigestPeriod(frequency)), now)
+		if err != nil {
+			ctx.Logger.Error("Failed to build digest", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build digest"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"digest": digest})
+	}
+}
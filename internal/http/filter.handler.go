@@ -0,0 +1,123 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+)
+
+func GetSyncFilters(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var filters []entity.SyncFilter
+		if err := ctx.DB.Where("project_id = ?", projectID).Order("created_at").Find(&filters).Error; err != nil {
+			ctx.Logger.Error("Failed to get sync filters", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sync filters"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"filters": filters})
+	}
+}
+
+func CreateSyncFilter(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		type createSyncFilterRequest struct {
+			EntityType  string `json:"entityType" binding:"required,oneof=dataset table"`
+			Action      string `json:"action" binding:"required,oneof=include exclude"`
+			PatternType string `json:"patternType" binding:"required,oneof=glob regex"`
+			Pattern     string `json:"pattern" binding:"required"`
+		}
+
+		var request createSyncFilterRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		if err := services.ValidateFilterPattern(request.PatternType, request.Pattern); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		filter := entity.SyncFilter{
+			ProjectID:   uuid.MustParse(projectID),
+			EntityType:  request.EntityType,
+			Action:      request.Action,
+			PatternType: request.PatternType,
+			Pattern:     request.Pattern,
+		}
+
+		if err := ctx.DB.Create(&filter).Error; err != nil {
+			ctx.Logger.Error("Failed to create sync filter", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create sync filter"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"filter": filter})
+	}
+}
+
+func DeleteSyncFilter(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		filterID := c.Param("filterID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		if err := ctx.DB.Where("id = ? AND project_id = ?", filterID, projectID).Delete(&entity.SyncFilter{}).Error; err != nil {
+			ctx.Logger.Error("Failed to delete sync filter", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sync filter"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Sync filter deleted successfully"})
+	}
+}
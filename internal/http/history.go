@@ -0,0 +1,50 @@
+package http
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/services"
+)
+
+// pointInTime reads the at (RFC 3339 timestamp) or sync_id query parameter
+// of the listing endpoints. It returns nil if neither is set, in which case
+// the current state is listed.
+func pointInTime(ctx *appcontext.Context, c *gin.Context, projectID uuid.UUID) (*time.Time, error) {
+	return pointInTimeParams(ctx, c, projectID, "sync_id", "at")
+}
+
+// isPointInTime reports whether the request asks for a point in time, which
+// may also be one at which now deleted entities still existed.
+func isPointInTime(c *gin.Context) bool {
+	return c.Query("sync_id") != "" || c.Query("at") != ""
+}
+
+// pointInTimeParams reads a point in time given either as a sync ID in the
+// syncParam query parameter or as an RFC 3339 timestamp in atParam.
+func pointInTimeParams(ctx *appcontext.Context, c *gin.Context, projectID uuid.UUID, syncParam, atParam string) (*time.Time, error) {
+	if syncID := c.Query(syncParam); syncID != "" {
+		id, err := uuid.Parse(syncID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", syncParam, err)
+		}
+		at, err := services.SyncTime(ctx.DB, projectID, id)
+		if err != nil {
+			return nil, err
+		}
+		return &at, nil
+	}
+
+	if value := c.Query(atParam); value != "" {
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s, expected an RFC 3339 timestamp: %w", atParam, err)
+		}
+		return &at, nil
+	}
+
+	return nil, nil
+}
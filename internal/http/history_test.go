@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestDeletedEntitiesOnlyReadableInHistory(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", connectorTable("orders", 100, "id", "amount"))
+	conn.AddTable("sales", connectorTable("refunds", 10, "id"))
+
+	sync := func() {
+		t.Helper()
+		run, err := services.StartSync(ctx.DB, project.ID, services.SyncOptions{Trigger: entity.SyncTriggerManual})
+		if err != nil {
+			t.Fatalf("StartSync: %v", err)
+		}
+		if _, err := services.SyncProject(ctx, context.Background(), project.ID, run, conn, services.SyncOptions{Trigger: entity.SyncTriggerManual}); err != nil {
+			t.Fatalf("SyncProject: %v", err)
+		}
+	}
+	sync()
+	at := time.Now().UTC().Format(time.RFC3339Nano)
+
+	var refunds entity.Table
+	if err := ctx.DB.Where("name = ?", "refunds").First(&refunds).Error; err != nil {
+		t.Fatalf("failed to get table: %v", err)
+	}
+	conn.RemoveTable("sales", "refunds")
+	sync()
+
+	description := "Refunded orders"
+	if code := serve(t, ctx, http.MethodPatch, "/api/v1/tables/"+refunds.ID.String(), user.ID, curatedFieldsRequest{CuratedDescription: &description}, nil); code != http.StatusUnauthorized {
+		t.Errorf("PATCH deleted table = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := serve(t, ctx, http.MethodGet, "/api/v1/columns/"+refunds.ID.String(), user.ID, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("GET columns of deleted table = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	var response struct {
+		Columns []map[string]interface{} `json:"columns"`
+	}
+	if code := serve(t, ctx, http.MethodGet, "/api/v1/columns/"+refunds.ID.String()+"?at="+at, user.ID, nil, &response); code != http.StatusOK {
+		t.Fatalf("GET columns of deleted table before it was deleted = %d, want %d", code, http.StatusOK)
+	}
+	if len(response.Columns) != 1 || response.Columns[0]["path"] != "id" {
+		t.Errorf("columns = %+v, want id", response.Columns)
+	}
+}
@@ -44,6 +44,7 @@ func (h *APIService) setupRoutes() {
 	h.setupCompanyRoutes(v1)
 	h.setupAnalyticsRoutes(v1)
 	h.setupSearchRoutes(v1)
+	h.setupNotificationRoutes(v1)
 }
 
 func (h *APIService) healthCheck(c *gin.Context) {
@@ -68,9 +69,11 @@ func (h *APIService) setupSchemaRoutes(group *gin.RouterGroup) {
 	schema.Use(middleware.JWTAuthMiddleware())
 
 	schema.POST("/:projectID", FetchSchema(h.context))
+	schema.GET("/:projectID/jobs/:jobID", GetSyncJob(h.context))
 	schema.GET("/:projectID/syncs", GetSyncsByProjectID(h.context))
 	schema.GET("/:projectID/syncs/changelogs", GetSyncsWithChangelogByProjectID(h.context))
 	schema.GET("/:projectID/syncs/changelogs/:syncID", GetChangelogsBySyncID(h.context))
+	schema.GET("/:projectID/diff", GetSchemaDiff(h.context))
 }
 
 func (h *APIService) setupAnalyticsRoutes(group *gin.RouterGroup) {
@@ -87,6 +90,36 @@ func (h *APIService) setupProjectRoutes(group *gin.RouterGroup) {
 	projects.POST("/create", CreateProject(h.context))
 	projects.GET("/", GetProjectsByUserID(h.context))
 	projects.GET("/:projectID/hasKey", GetProjectHasKey(h.context))
+	projects.PUT("/:projectID/settings", UpdateProjectSettings(h.context))
+	projects.POST("/:projectID/connection", CreateConnection(h.context))
+	projects.GET("/:projectID/schedule", GetSyncSchedule(h.context))
+	projects.PUT("/:projectID/schedule", UpsertSyncSchedule(h.context))
+	projects.DELETE("/:projectID/schedule", DeleteSyncSchedule(h.context))
+	projects.GET("/:projectID/filters", GetSyncFilters(h.context))
+	projects.POST("/:projectID/filters", CreateSyncFilter(h.context))
+	projects.DELETE("/:projectID/filters/:filterID", DeleteSyncFilter(h.context))
+	projects.GET("/:projectID/renames", GetColumnRenames(h.context))
+	projects.POST("/:projectID/renames/:renameID/confirm", ConfirmColumnRename(h.context))
+	projects.POST("/:projectID/renames/:renameID/reject", RejectColumnRename(h.context))
+	projects.GET("/:projectID/policies", GetCompatibilityPolicies(h.context))
+	projects.PUT("/:projectID/policies/:datasetID", UpsertCompatibilityPolicy(h.context))
+	projects.DELETE("/:projectID/policies/:datasetID", DeleteCompatibilityPolicy(h.context))
+	projects.GET("/:projectID/changelogs", QueryChangelogs(h.context))
+	projects.GET("/:projectID/changelogs/entities/:entityID", GetEntityHistory(h.context))
+	projects.GET("/:projectID/digest", GetDigestSubscription(h.context))
+	projects.PUT("/:projectID/digest", UpsertDigestSubscription(h.context))
+	projects.DELETE("/:projectID/digest", DeleteDigestSubscription(h.context))
+	projects.GET("/:projectID/digest/preview", PreviewDigest(h.context))
+	projects.GET("/:projectID/watches", GetWatches(h.context))
+	projects.POST("/:projectID/watches", WatchEntity(h.context))
+	projects.DELETE("/:projectID/watches/:watchID", DeleteWatch(h.context))
+	projects.GET("/:projectID/watchers/:entityType/:entityID", GetEntityWatchers(h.context))
+	projects.GET("/:projectID/webhooks", GetWebhooks(h.context))
+	projects.POST("/:projectID/webhooks", CreateWebhook(h.context))
+	projects.PATCH("/:projectID/webhooks/:webhookID", UpdateWebhook(h.context))
+	projects.DELETE("/:projectID/webhooks/:webhookID", DeleteWebhook(h.context))
+	projects.GET("/:projectID/webhooks/:webhookID/deliveries", GetWebhookDeliveries(h.context))
+	projects.POST("/:projectID/webhooks/:webhookID/deliveries/:deliveryID/redeliver", RedeliverWebhookDelivery(h.context))
 }
 
 func (h *APIService) setupDatasetRoutes(group *gin.RouterGroup) {
@@ -94,6 +127,7 @@ func (h *APIService) setupDatasetRoutes(group *gin.RouterGroup) {
 	datasets.Use(middleware.JWTAuthMiddleware())
 
 	datasets.GET("/:projectID", GetDatasets(h.context))
+	datasets.PATCH("/:datasetID", UpdateDataset(h.context))
 }
 
 func (h *APIService) setupTableRoutes(group *gin.RouterGroup) {
@@ -102,6 +136,7 @@ func (h *APIService) setupTableRoutes(group *gin.RouterGroup) {
 
 	tables.GET("/", GetTables(h.context))
 	tables.GET("/:datasetID", GetTablesByDatasetID(h.context))
+	tables.PATCH("/:tableID", UpdateTable(h.context))
 }
 
 func (h *APIService) setupColumnRoutes(group *gin.RouterGroup) {
@@ -109,6 +144,7 @@ func (h *APIService) setupColumnRoutes(group *gin.RouterGroup) {
 	columns.Use(middleware.JWTAuthMiddleware())
 
 	columns.GET("/:tableID", GetColumnsByTableID(h.context))
+	columns.PATCH("/:columnID", UpdateColumn(h.context))
 }
 
 func (h *APIService) setupFileRoutes(group *gin.RouterGroup) {
@@ -132,3 +168,12 @@ func (h *APIService) setupSearchRoutes(group *gin.RouterGroup) {
 
 	search.GET("/", SearchResources(h.context))
 }
+
+func (h *APIService) setupNotificationRoutes(group *gin.RouterGroup) {
+	notifications := group.Group("/notifications")
+	notifications.Use(middleware.JWTAuthMiddleware())
+
+	notifications.GET("/", GetNotifications(h.context))
+	notifications.POST("/read", MarkAllNotificationsRead(h.context))
+	notifications.POST("/:notificationID/read", MarkNotificationRead(h.context))
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/connectors"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve sends a request to the API as the given user and decodes the JSON
+// response into out, if it is not nil.
+func serve(t *testing.T, ctx *appcontext.Context, method, target string, userID uuid.UUID, body interface{}, out interface{}) int {
+	t.Helper()
+
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatalf("failed to encode request: %v", err)
+		}
+	}
+
+	token, err := utils.GenerateJWT(userID.String())
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	request := httptest.NewRequest(method, target, &reader)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+	NewHTTPService(ctx).Engine().ServeHTTP(recorder, request)
+
+	if out != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			t.Fatalf("failed to decode response %q: %v", recorder.Body.String(), err)
+		}
+	}
+
+	return recorder.Code
+}
+
+// connectorTable describes a table of STRING columns for a fake connector.
+func connectorTable(name string, rows uint64, columns ...string) connectors.TableMetadata {
+	metadata := connectors.TableMetadata{Name: name, Type: connectors.TableTypeTable, RowCount: rows}
+	for _, column := range columns {
+		metadata.Columns = append(metadata.Columns, connectors.ColumnMetadata{Name: column, Type: "STRING", Mode: connectors.ColumnModeNullable})
+	}
+	return metadata
+}
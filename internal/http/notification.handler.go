@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+)
+
+// GetNotifications lists the current user's notifications, newest first.
+// With unread=true, only unread notifications are returned.
+func GetNotifications(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		page, pageSize := pageParams(c)
+
+		query := ctx.DB.Model(&entity.Notification{}).Where("user_id = ?", userID)
+		if c.Query("unread") == "true" {
+			query = query.Where("read_at IS NULL")
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			ctx.Logger.Error("Failed to count notifications", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+			return
+		}
+
+		var unread int64
+		if err := ctx.DB.Model(&entity.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread).Error; err != nil {
+			ctx.Logger.Error("Failed to count unread notifications", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+			return
+		}
+
+		var notifications []entity.Notification
+		if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&notifications).Error; err != nil {
+			ctx.Logger.Error("Failed to get notifications", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"notifications": notifications,
+			"unread":        unread,
+			"total":         total,
+			"page":          page,
+			"page_size":     pageSize,
+		})
+	}
+}
+
+func MarkNotificationRead(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notificationID := c.Param("notificationID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if err := ctx.DB.Model(&entity.Notification{}).
+			Where("id = ? AND user_id = ? AND read_at IS NULL", notificationID, userID).
+			Update("read_at", time.Now()).Error; err != nil {
+			ctx.Logger.Error("Failed to mark notification as read", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification as read"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+	}
+}
+
+func MarkAllNotificationsRead(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if err := ctx.DB.Model(&entity.Notification{}).
+			Where("user_id = ? AND read_at IS NULL", userID).
+			Update("read_at", time.Now()).Error; err != nil {
+			ctx.Logger.Error("Failed to mark notifications as read", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read"})
+	}
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageParams reads the page and page_size query parameters. Pages start at 1,
+// invalid values fall back to the defaults.
+func pageParams(c *gin.Context) (page, pageSize int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err = strconv.Atoi(c.Query("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return page, pageSize
+}
+
+// changelogCursor points at the last changelog entry of a page. Entries are
+// listed newest first, ties on the creation time are broken by ID.
+type changelogCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func encodeCursor(cursor changelogCursor) string {
+	cursorBytes, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(cursorBytes)
+}
+
+func decodeCursor(value string) (*changelogCursor, error) {
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	var cursor changelogCursor
+	if err := json.Unmarshal(cursorBytes, &cursor); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return &cursor, nil
+}
+
+// limitParam reads the limit query parameter of cursor paginated endpoints.
+func limitParam(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		return defaultPageSize
+	}
+	if limit > maxPageSize {
+		return maxPageSize
+	}
+	return limit
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func GetCompatibilityPolicies(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var policies []entity.CompatibilityPolicy
+		if err := ctx.DB.Where("project_id = ?", projectID).Find(&policies).Error; err != nil {
+			ctx.Logger.Error("Failed to get compatibility policies", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get compatibility policies"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"policies": policies})
+	}
+}
+
+func UpsertCompatibilityPolicy(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		datasetID := c.Param("datasetID")
+
+		type upsertPolicyRequest struct {
+			Level string `json:"level" binding:"required"`
+		}
+
+		var request upsertPolicyRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		if !services.ValidCompatibilityLevel(request.Level) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "level must be backward, forward or full"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasDatasetAccess(ctx, userID, uuid.MustParse(datasetID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var dataset entity.Dataset
+		if err := ctx.DB.Where("id = ? AND project_id = ?", datasetID, projectID).First(&dataset).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dataset not found"})
+			return
+		}
+
+		var policy entity.CompatibilityPolicy
+		err = ctx.DB.Where("dataset_id = ?", dataset.ID).First(&policy).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.Logger.Error("Failed to get compatibility policy", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get compatibility policy"})
+			return
+		}
+
+		policy.ProjectID = dataset.ProjectID
+		policy.DatasetID = dataset.ID
+		policy.Level = request.Level
+
+		if err := ctx.DB.Save(&policy).Error; err != nil {
+			ctx.Logger.Error("Failed to save compatibility policy", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save compatibility policy"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"policy": policy})
+	}
+}
+
+func DeleteCompatibilityPolicy(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		datasetID := c.Param("datasetID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		if err := ctx.DB.Unscoped().Where("project_id = ? AND dataset_id = ?", projectID, datasetID).Delete(&entity.CompatibilityPolicy{}).Error; err != nil {
+			ctx.Logger.Error("Failed to delete compatibility policy", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete compatibility policy"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Compatibility policy deleted"})
+	}
+}
@@ -1,12 +1,14 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kerem-kaynak/katalog/internal/appcontext"
 	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
 	"github.com/kerem-kaynak/katalog/internal/utils"
 	"go.uber.org/zap"
 )
@@ -111,3 +113,75 @@ func CreateProject(ctx *appcontext.Context) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"project": project})
 	}
 }
+
+func UpdateProjectSettings(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		type updateProjectSettingsRequest struct {
+			SyncConcurrency       *int    `json:"syncConcurrency"`
+			TolerateFailures      *bool   `json:"tolerateFailures"`
+			QueryModifiedTimes    *bool   `json:"queryModifiedTimes"`
+			DescriptionPrecedence *string `json:"descriptionPrecedence"`
+		}
+
+		var request updateProjectSettingsRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		updates := map[string]interface{}{}
+		if request.SyncConcurrency != nil {
+			if *request.SyncConcurrency < 1 || *request.SyncConcurrency > services.MaxSyncConcurrency {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("syncConcurrency must be between 1 and %d", services.MaxSyncConcurrency)})
+				return
+			}
+			updates["sync_concurrency"] = *request.SyncConcurrency
+		}
+		if request.TolerateFailures != nil {
+			updates["tolerate_failures"] = *request.TolerateFailures
+		}
+		if request.QueryModifiedTimes != nil {
+			updates["query_modified_times"] = *request.QueryModifiedTimes
+		}
+		if request.DescriptionPrecedence != nil {
+			if !utils.ValidDescriptionPrecedence(*request.DescriptionPrecedence) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "descriptionPrecedence must be curated or source"})
+				return
+			}
+			updates["description_precedence"] = *request.DescriptionPrecedence
+		}
+
+		var project entity.Project
+		if err := ctx.DB.Where("id = ?", projectID).First(&project).Error; err != nil {
+			ctx.Logger.Error("Failed to get project", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+			return
+		}
+
+		if len(updates) > 0 {
+			if err := ctx.DB.Model(&project).Updates(updates).Error; err != nil {
+				ctx.Logger.Error("Failed to update project settings", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project settings"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"project": project})
+	}
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+)
+
+func GetColumnRenames(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		query := ctx.DB.Where("project_id = ?", projectID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var renames []entity.ColumnRename
+		if err := query.Order("created_at DESC").Find(&renames).Error; err != nil {
+			ctx.Logger.Error("Failed to get column renames", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get column renames"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"renames": renames})
+	}
+}
+
+func ConfirmColumnRename(ctx *appcontext.Context) gin.HandlerFunc {
+	return resolveColumnRename(ctx, true)
+}
+
+func RejectColumnRename(ctx *appcontext.Context) gin.HandlerFunc {
+	return resolveColumnRename(ctx, false)
+}
+
+func resolveColumnRename(ctx *appcontext.Context, confirm bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		renameID := c.Param("renameID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var rename entity.ColumnRename
+		if err := ctx.DB.Where("id = ? AND project_id = ?", renameID, projectID).First(&rename).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Column rename not found"})
+			return
+		}
+
+		resolved, err := services.ResolveColumnRename(ctx, userID, rename.ID, confirm)
+		if errors.Is(err, services.ErrColumnRenameResolved) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Column rename has already been resolved"})
+			return
+		}
+		if err != nil {
+			ctx.Logger.Error("Failed to resolve column rename", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve column rename"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"rename": resolved})
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func GetSyncSchedule(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var schedule entity.SyncSchedule
+		if err := ctx.DB.Where("project_id = ?", projectID).First(&schedule).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Project has no sync schedule"})
+				return
+			}
+			ctx.Logger.Error("Failed to get sync schedule", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sync schedule"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"schedule": schedule})
+	}
+}
+
+func UpsertSyncSchedule(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		type upsertScheduleRequest struct {
+			CronExpression string `json:"cronExpression" binding:"required"`
+			Timezone       string `json:"timezone"`
+			Enabled        *bool  `json:"enabled"`
+		}
+
+		var request upsertScheduleRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		if request.Timezone == "" {
+			request.Timezone = "UTC"
+		}
+		enabled := true
+		if request.Enabled != nil {
+			enabled = *request.Enabled
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		nextRunAt, err := services.NextScheduledRun(request.CronExpression, request.Timezone, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var schedule entity.SyncSchedule
+		err = ctx.DB.Where("project_id = ?", projectID).First(&schedule).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.Logger.Error("Failed to get sync schedule", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sync schedule"})
+			return
+		}
+
+		schedule.ProjectID = uuid.MustParse(projectID)
+		schedule.CronExpression = request.CronExpression
+		schedule.Timezone = request.Timezone
+		schedule.Enabled = enabled
+		schedule.NextRunAt = &nextRunAt
+
+		if err := ctx.DB.Save(&schedule).Error; err != nil {
+			ctx.Logger.Error("Failed to save sync schedule", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save sync schedule"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"schedule": schedule})
+	}
+}
+
+func DeleteSyncSchedule(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		// Hard delete, so the project can get a new schedule without colliding on the unique index
+		if err := ctx.DB.Unscoped().Where("project_id = ?", projectID).Delete(&entity.SyncSchedule{}).Error; err != nil {
+			ctx.Logger.Error("Failed to delete sync schedule", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sync schedule"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Sync schedule deleted successfully"})
+	}
+}
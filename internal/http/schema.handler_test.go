@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestGetSchemaDiffRange(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	now := time.Now().UTC()
+	tests := []struct {
+		name   string
+		query  url.Values
+		status int
+	}{
+		{"from before to", url.Values{"from": {now.Add(-time.Hour).Format(time.RFC3339)}, "to": {now.Format(time.RFC3339)}}, http.StatusOK},
+		{"from up to now", url.Values{"from": {now.Add(-time.Hour).Format(time.RFC3339)}}, http.StatusOK},
+		{"from after to", url.Values{"from": {now.Format(time.RFC3339)}, "to": {now.Add(-time.Hour).Format(time.RFC3339)}}, http.StatusBadRequest},
+		{"from in the future", url.Values{"from": {now.Add(time.Hour).Format(time.RFC3339)}}, http.StatusBadRequest},
+		{"no from", url.Values{"to": {now.Format(time.RFC3339)}}, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		target := "/api/v1/schema/" + project.ID.String() + "/diff?" + test.query.Encode()
+		if code := serve(t, ctx, http.MethodGet, target, user.ID, nil, nil); code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.name, code, test.status)
+		}
+	}
+}
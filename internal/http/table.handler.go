@@ -1,12 +1,14 @@
 package http
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kerem-kaynak/katalog/internal/appcontext"
 	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
 	"github.com/kerem-kaynak/katalog/internal/utils"
 	"go.uber.org/zap"
 )
@@ -34,16 +36,21 @@ func GetTables(ctx *appcontext.Context) gin.HandlerFunc {
 			return
 		}
 
+		// Tables may belong to different projects, each with its own precedence
+		precedences := make(map[uuid.UUID]string)
 		var response []map[string]interface{}
 		for _, table := range tables {
-			response = append(response, map[string]interface{}{
-				"id":           table.ID,
-				"name":         table.Name,
-				"description":  table.Description,
-				"dataset_id":   table.DatasetID,
-				"column_count": len(table.Columns),
-				"row_count":    table.RowCount,
-			})
+			precedence, ok := precedences[table.DatasetID]
+			if !ok {
+				precedence, err = utils.DatasetDescriptionPrecedence(ctx.DB, table.DatasetID)
+				if err != nil {
+					ctx.Logger.Error("Failed to get description precedence", zap.Error(err))
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tables"})
+					return
+				}
+				precedences[table.DatasetID] = precedence
+			}
+			response = append(response, tableResponse(&table, precedence))
 		}
 
 		c.JSON(http.StatusOK, gin.H{"tables": response})
@@ -62,30 +69,128 @@ func GetTablesByDatasetID(ctx *appcontext.Context) gin.HandlerFunc {
 		}
 
 		userHasAccess := utils.UserHasDatasetAccess(ctx, userID, uuid.MustParse(datasetID))
+		if isPointInTime(c) {
+			userHasAccess = utils.UserHasDatasetHistoryAccess(ctx, userID, uuid.MustParse(datasetID))
+		}
 		if !userHasAccess {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
 			return
 		}
 
+		var dataset entity.Dataset
+		if err := ctx.DB.Unscoped().Where("id = ?", datasetID).First(&dataset).Error; err != nil {
+			ctx.Logger.Error("Failed to get dataset", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tables"})
+			return
+		}
+
+		at, err := pointInTime(ctx, c, dataset.ProjectID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		var tables []entity.Table
-		if err := ctx.DB.Where("dataset_id = ?", datasetID).Preload("Columns").Find(&tables).Error; err != nil {
+		if at != nil {
+			tables, err = services.DatasetStateAt(ctx.DB, dataset.ID, *at)
+			if err != nil {
+				ctx.Logger.Error("Failed to reconstruct tables", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tables"})
+				return
+			}
+		} else if err := ctx.DB.Where("dataset_id = ?", datasetID).Preload("Columns").Find(&tables).Error; err != nil {
 			ctx.Logger.Error("Failed to get tables", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tables"})
 			return
 		}
 
+		precedence, err := utils.DatasetDescriptionPrecedence(ctx.DB, uuid.MustParse(datasetID))
+		if err != nil {
+			ctx.Logger.Error("Failed to get description precedence", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tables"})
+			return
+		}
+
 		var response []map[string]interface{}
 		for _, table := range tables {
-			response = append(response, map[string]interface{}{
-				"id":           table.ID,
-				"name":         table.Name,
-				"description":  table.Description,
-				"dataset_id":   table.DatasetID,
-				"column_count": len(table.Columns),
-				"row_count":    table.RowCount,
-			})
+			response = append(response, tableResponse(&table, precedence))
 		}
 
 		c.JSON(http.StatusOK, gin.H{"tables": response})
 	}
 }
+
+func UpdateTable(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tableID := c.Param("tableID")
+
+		var request curatedFieldsRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasTableAccess(ctx, userID, uuid.MustParse(tableID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		table, err := services.EditTable(ctx, userID, uuid.MustParse(tableID), request.fields())
+		if err != nil {
+			ctx.Logger.Error("Failed to update table", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update table"})
+			return
+		}
+
+		precedence, err := utils.DatasetDescriptionPrecedence(ctx.DB, table.DatasetID)
+		if err != nil {
+			ctx.Logger.Error("Failed to get description precedence", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update table"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"table": tableResponse(table, precedence)})
+	}
+}
+
+func tableResponse(table *entity.Table, precedence string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                  table.ID,
+		"name":                table.Name,
+		"description":         utils.EffectiveDescription(precedence, table.Description, table.CuratedDescription),
+		"source_description":  table.Description,
+		"curated_description": table.CuratedDescription,
+		"owner":               table.Owner,
+		"tags":                rawJSON(table.Tags),
+		"dataset_id":          table.DatasetID,
+		"column_count":        len(table.Columns),
+		"row_count":           table.RowCount,
+		"type":                table.Type,
+		"num_bytes":           table.NumBytes,
+		"location":            table.Location,
+		"time_partitioning":   rawJSON(table.TimePartitioning),
+		"range_partitioning":  rawJSON(table.RangePartitioning),
+		"clustering":          rawJSON(table.Clustering),
+		"labels":              rawJSON(table.Labels),
+		"expiration_time":     table.ExpirationTime,
+		"creation_time":       table.CreationTime,
+		"last_modified_time":  table.LastModifiedTime,
+	}
+}
+
+// rawJSON embeds metadata stored as JSON text into the response as is.
+func rawJSON(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return json.RawMessage(value)
+}
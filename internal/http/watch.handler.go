@@ -0,0 +1,164 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+)
+
+// GetWatches lists the current user's watches in the project.
+func GetWatches(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var watches []entity.Watch
+		if err := ctx.DB.Where("user_id = ? AND project_id = ?", userID, projectID).Order("entity_name").Find(&watches).Error; err != nil {
+			ctx.Logger.Error("Failed to get watches", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get watches"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"watches": watches})
+	}
+}
+
+// WatchEntity makes the current user watch a dataset, table or column. If
+// they already watch it, only the email setting is updated.
+func WatchEntity(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		type watchRequest struct {
+			EntityType string    `json:"entityType" binding:"required"`
+			EntityID   uuid.UUID `json:"entityID" binding:"required"`
+			Email      bool      `json:"email"`
+		}
+
+		var request watchRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		if !services.ValidWatchEntityType(request.EntityType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "entityType must be dataset, table or column"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		watch, err := services.WatchEntity(ctx.DB, userID, uuid.MustParse(projectID), request.EntityType, request.EntityID, request.Email)
+		if err != nil {
+			if errors.Is(err, services.ErrWatchedEntityNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+				return
+			}
+			ctx.Logger.Error("Failed to watch entity", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to watch entity"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"watch": watch})
+	}
+}
+
+func DeleteWatch(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		watchID := c.Param("watchID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		if err := ctx.DB.Unscoped().Where("id = ? AND user_id = ? AND project_id = ?", watchID, userID, projectID).Delete(&entity.Watch{}).Error; err != nil {
+			ctx.Logger.Error("Failed to delete watch", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete watch"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Watch deleted"})
+	}
+}
+
+// GetEntityWatchers lists who watches an entity, its parents or its children,
+// e.g. who depends on a table before it is dropped.
+func GetEntityWatchers(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		entityType := c.Param("entityType")
+		entityID := c.Param("entityID")
+
+		if !services.ValidWatchEntityType(entityType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "entity type must be dataset, table or column"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		watchers, err := services.EntityWatchers(ctx.DB, uuid.MustParse(projectID), entityType, uuid.MustParse(entityID))
+		if err != nil {
+			if errors.Is(err, services.ErrWatchedEntityNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+				return
+			}
+			ctx.Logger.Error("Failed to get watchers", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get watchers"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"watchers": watchers})
+	}
+}
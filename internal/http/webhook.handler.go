@@ -0,0 +1,342 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/services"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func webhookResponse(webhook *entity.Webhook) gin.H {
+	return gin.H{
+		"id":         webhook.ID,
+		"project_id": webhook.ProjectID,
+		"url":        webhook.URL,
+		"events":     strings.Split(webhook.Events, ","),
+		"enabled":    webhook.Enabled,
+		"created_by": webhook.CreatedBy,
+		"created_at": webhook.CreatedAt,
+		"updated_at": webhook.UpdatedAt,
+	}
+}
+
+// validateWebhook checks the URL and events of a webhook request and returns
+// a message for the client if they are invalid.
+func validateWebhook(c *gin.Context, webhookURL string, events []string) string {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "url must be an absolute http or https URL"
+	}
+	if err := services.ValidateWebhookHost(c.Request.Context(), parsed.Hostname()); err != nil {
+		if errors.Is(err, services.ErrWebhookAddressNotAllowed) {
+			return "url must not point to a private, loopback or link-local address"
+		}
+		return "url host could not be resolved"
+	}
+	if len(events) == 0 {
+		return "events must not be empty"
+	}
+	for _, event := range events {
+		if !services.ValidWebhookEvent(event) {
+			return "events must be any of " + strings.Join(services.WebhookEvents, ", ")
+		}
+	}
+	return ""
+}
+
+func GetWebhooks(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var webhooks []entity.Webhook
+		if err := ctx.DB.Where("project_id = ?", projectID).Order("created_at").Find(&webhooks).Error; err != nil {
+			ctx.Logger.Error("Failed to get webhooks", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhooks"})
+			return
+		}
+
+		response := make([]gin.H, len(webhooks))
+		for i := range webhooks {
+			response[i] = webhookResponse(&webhooks[i])
+		}
+
+		c.JSON(http.StatusOK, gin.H{"webhooks": response})
+	}
+}
+
+// CreateWebhook registers a webhook for the project. The secret that signs
+// its payloads is only returned in this response.
+func CreateWebhook(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+
+		type createWebhookRequest struct {
+			URL    string   `json:"url" binding:"required"`
+			Events []string `json:"events" binding:"required"`
+		}
+
+		var request createWebhookRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		if message := validateWebhook(c, request.URL, request.Events); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		secret, err := services.NewWebhookSecret()
+		if err != nil {
+			ctx.Logger.Error("Failed to generate webhook secret", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+			return
+		}
+
+		webhook := entity.Webhook{
+			ProjectID: uuid.MustParse(projectID),
+			URL:       request.URL,
+			Secret:    secret,
+			Events:    strings.Join(request.Events, ","),
+			Enabled:   true,
+			CreatedBy: &userID,
+		}
+		if err := ctx.DB.Create(&webhook).Error; err != nil {
+			ctx.Logger.Error("Failed to create webhook", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+			return
+		}
+
+		response := webhookResponse(&webhook)
+		response["secret"] = webhook.Secret
+
+		c.JSON(http.StatusCreated, gin.H{"webhook": response})
+	}
+}
+
+func UpdateWebhook(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		webhookID := c.Param("webhookID")
+
+		type updateWebhookRequest struct {
+			URL     *string  `json:"url"`
+			Events  []string `json:"events"`
+			Enabled *bool    `json:"enabled"`
+		}
+
+		var request updateWebhookRequest
+		if err := c.BindJSON(&request); err != nil {
+			ctx.Logger.Error("Failed to bind request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind request"})
+			return
+		}
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var webhook entity.Webhook
+		if err := ctx.DB.Where("id = ? AND project_id = ?", webhookID, projectID).First(&webhook).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+				return
+			}
+			ctx.Logger.Error("Failed to get webhook", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook"})
+			return
+		}
+
+		if request.URL != nil {
+			webhook.URL = *request.URL
+		}
+		if request.Events != nil {
+			webhook.Events = strings.Join(request.Events, ",")
+		}
+		if request.Enabled != nil {
+			webhook.Enabled = *request.Enabled
+		}
+
+		if message := validateWebhook(c, webhook.URL, strings.Split(webhook.Events, ",")); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		if err := ctx.DB.Model(&webhook).Select("url", "events", "enabled").Updates(&webhook).Error; err != nil {
+			ctx.Logger.Error("Failed to update webhook", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"webhook": webhookResponse(&webhook)})
+	}
+}
+
+func DeleteWebhook(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		webhookID := c.Param("webhookID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		if err := ctx.DB.Where("id = ? AND project_id = ?", webhookID, projectID).Delete(&entity.Webhook{}).Error; err != nil {
+			ctx.Logger.Error("Failed to delete webhook", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+	}
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook, newest first,
+// optionally filtered by status.
+func GetWebhookDeliveries(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		webhookID := c.Param("webhookID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var webhook entity.Webhook
+		if err := ctx.DB.Where("id = ? AND project_id = ?", webhookID, projectID).First(&webhook).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+
+		page, pageSize := pageParams(c)
+
+		query := ctx.DB.Model(&entity.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			ctx.Logger.Error("Failed to count webhook deliveries", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook deliveries"})
+			return
+		}
+
+		var deliveries []entity.WebhookDelivery
+		if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+			ctx.Logger.Error("Failed to get webhook deliveries", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook deliveries"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"deliveries": deliveries,
+			"total":      total,
+			"page":       page,
+			"page_size":  pageSize,
+		})
+	}
+}
+
+func RedeliverWebhookDelivery(ctx *appcontext.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.Param("projectID")
+		webhookID := c.Param("webhookID")
+		deliveryID := c.Param("deliveryID")
+
+		userID, err := utils.GetUserIDFromClaims(c)
+		if err != nil {
+			ctx.Logger.Error("Failed to get user ID from claims", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userHasAccess := utils.UserHasProjectAccess(ctx, userID, uuid.MustParse(projectID))
+		if !userHasAccess {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User does not have access to this resource"})
+			return
+		}
+
+		var webhook entity.Webhook
+		if err := ctx.DB.Where("id = ? AND project_id = ?", webhookID, projectID).First(&webhook).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+
+		delivery, err := services.RedeliverWebhook(ctx.DB, webhook.ID, uuid.MustParse(deliveryID))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+				return
+			}
+			ctx.Logger.Error("Failed to redeliver webhook", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver webhook"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"delivery": delivery})
+	}
+}
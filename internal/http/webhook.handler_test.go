@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestCreateWebhookRejectsPrivateURLs(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	target := "/api/v1/projects/" + project.ID.String() + "/webhooks"
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.1.2.3/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+	} {
+		body := map[string]interface{}{"url": url, "events": []string{entity.WebhookEventSyncCompleted}}
+		if code := serve(t, ctx, http.MethodPost, target, user.ID, body, nil); code != http.StatusBadRequest {
+			t.Errorf("POST webhook for %s = %d, want %d", url, code, http.StatusBadRequest)
+		}
+	}
+
+	var webhooks int64
+	ctx.DB.Model(&entity.Webhook{}).Count(&webhooks)
+	if webhooks != 0 {
+		t.Errorf("got %d webhooks, want none", webhooks)
+	}
+}
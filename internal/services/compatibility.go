@@ -0,0 +1,28 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"gorm.io/gorm"
+)
+
+// LoadCompatibilityPolicies returns the compatibility levels of the project's
+// datasets, keyed by dataset ID.
+func LoadCompatibilityPolicies(db *gorm.DB, projectID uuid.UUID) (map[uuid.UUID]string, error) {
+	var policies []entity.CompatibilityPolicy
+	if err := db.Where("project_id = ?", projectID).Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to get compatibility policies: %w", err)
+	}
+
+	levels := make(map[uuid.UUID]string)
+	for _, policy := range policies {
+		levels[policy.DatasetID] = policy.Level
+	}
+	return levels, nil
+}
+
+func ValidCompatibilityLevel(level string) bool {
+	return level == entity.CompatibilityBackward || level == entity.CompatibilityForward || level == entity.CompatibilityFull
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/connectors"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+)
+
+const (
+	DefaultSyncConcurrency = 4
+	MaxSyncConcurrency     = 32
+)
+
+// crawledDataset is a dataset listed at the source. err is set if listing
+// its tables failed, in which case tables is empty. modifiedTimesErr is set if
+// listing the last modified times of its tables failed, in which case every
+// table was described.
+type crawledDataset struct {
+	metadata         connectors.DatasetMetadata
+	tables           []crawledTable
+	err              error
+	modifiedTimesErr error
+}
+
+// crawledTable is a table listed at the source. Its metadata is nil if the
+// table is unchanged since the last sync, or if describing it failed.
+type crawledTable struct {
+	name     string
+	metadata *connectors.TableMetadata
+	err      error
+}
+
+type describeTask struct {
+	dataset int
+	table   int
+}
+
+// crawl lists the datasets and tables of the source that pass the project's
+// filters and describes every table that may have changed since the last
+// sync, up to the project's concurrency at a time. Unless full is set, tables
+// whose last modified time is unchanged are skipped, if the project opted in
+// to listing those times and the connector can. A failure to describe a
+// single table is stored on that table. A failure to list the tables of a
+// dataset aborts the crawl, unless the project tolerates failures, in which
+// case it is stored on that dataset. No more tables are described once stop
+// is done, in which case the crawl fails.
+func crawl(stop context.Context, conn connectors.Connector, project *entity.Project, knownTables map[string]map[string]entity.Table, filters *SyncFilters, full bool) ([]crawledDataset, error) {
+	lister, canListModifiedTimes := conn.(connectors.ModifiedTimesLister)
+	listModifiedTimes := !full && project.QueryModifiedTimes && canListModifiedTimes
+
+	datasetMetas, err := conn.ListDatasets(stop)
+	if err != nil {
+		return nil, err
+	}
+
+	var datasets []crawledDataset
+	var tasks []describeTask
+
+	for _, dsMeta := range datasetMetas {
+		if !filters.DatasetAllowed(dsMeta.Name) {
+			continue
+		}
+
+		refs, err := conn.ListTables(stop, dsMeta.Name)
+		if err != nil {
+			if !project.TolerateFailures {
+				return nil, fmt.Errorf("failed to list tables of dataset %s: %w", dsMeta.Name, err)
+			}
+			datasets = append(datasets, crawledDataset{metadata: dsMeta, err: err})
+			continue
+		}
+
+		datasets = append(datasets, crawledDataset{metadata: dsMeta})
+		i := len(datasets) - 1
+
+		var modifiedTimes map[string]time.Time
+		if listModifiedTimes {
+			modifiedTimes, err = lister.ListModifiedTimes(stop, dsMeta.Name)
+			datasets[i].modifiedTimesErr = err
+		}
+
+		for _, ref := range refs {
+			if !filters.TableAllowed(dsMeta.Name, ref.Name) {
+				continue
+			}
+
+			datasets[i].tables = append(datasets[i].tables, crawledTable{name: ref.Name})
+			j := len(datasets[i].tables) - 1
+
+			existing, exists := knownTables[dsMeta.Name][ref.Name]
+			modified := modifiedTimes[ref.Name]
+			if exists && !modified.IsZero() && sameTime(existing.LastModifiedTime, optionalTime(modified)) {
+				continue
+			}
+
+			tasks = append(tasks, describeTask{dataset: i, table: j})
+		}
+	}
+
+	concurrency := project.SyncConcurrency
+	if concurrency < 1 {
+		concurrency = DefaultSyncConcurrency
+	}
+	if concurrency > MaxSyncConcurrency {
+		concurrency = MaxSyncConcurrency
+	}
+
+	taskCh := make(chan describeTask)
+	var wg sync.WaitGroup
+
+	// Every task writes to its own table, so the results need no locking
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskCh {
+				dataset := &datasets[task.dataset]
+				table := &dataset.tables[task.table]
+				table.metadata, table.err = conn.DescribeTable(stop, dataset.metadata.Name, table.name)
+			}
+		}()
+	}
+
+dispatch:
+	for _, task := range tasks {
+		select {
+		case taskCh <- task:
+		case <-stop.Done():
+			break dispatch
+		}
+	}
+	close(taskCh)
+	wg.Wait()
+
+	if err := stop.Err(); err != nil {
+		return nil, fmt.Errorf("sync was stopped: %w", err)
+	}
+
+	return datasets, nil
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// CuratedFields are the fields of a dataset, table or column that users edit
+// in Katalog. Nil fields are left unchanged. Owner names the person or team
+// responsible for the entity, Tags are free-form labels stored as a sorted
+// JSON array.
+type CuratedFields struct {
+	CuratedDescription *string
+	Owner              *string
+	Tags               *[]string
+}
+
+// curatedEdit is a single changed field, logged as a user edit.
+type curatedEdit struct {
+	column    string
+	fieldName string
+	oldValue  string
+	newValue  string
+}
+
+func (fields CuratedFields) edits(curatedDescription, owner, tags string) []curatedEdit {
+	var edits []curatedEdit
+	if fields.CuratedDescription != nil && *fields.CuratedDescription != curatedDescription {
+		edits = append(edits, curatedEdit{
+			column:    "curated_description",
+			fieldName: "CuratedDescription",
+			oldValue:  curatedDescription,
+			newValue:  *fields.CuratedDescription,
+		})
+	}
+	if fields.Owner != nil && strings.TrimSpace(*fields.Owner) != owner {
+		edits = append(edits, curatedEdit{
+			column:    "owner",
+			fieldName: "Owner",
+			oldValue:  owner,
+			newValue:  strings.TrimSpace(*fields.Owner),
+		})
+	}
+	if fields.Tags != nil {
+		if newTags := encodeTags(*fields.Tags); newTags != tags {
+			edits = append(edits, curatedEdit{
+				column:    "tags",
+				fieldName: "Tags",
+				oldValue:  tags,
+				newValue:  newTags,
+			})
+		}
+	}
+	return edits
+}
+
+// encodeTags trims, deduplicates and sorts tags and encodes them as a JSON
+// array. No tags are stored as the empty string.
+func encodeTags(tags []string) string {
+	seen := make(map[string]bool)
+	var cleaned []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		cleaned = append(cleaned, tag)
+	}
+	if len(cleaned) == 0 {
+		return ""
+	}
+	sort.Strings(cleaned)
+	return toJSON(cleaned)
+}
+
+// EditDataset applies a user's edits to a dataset, logs them to the changelog
+// and re-indexes the dataset.
+func EditDataset(ctx *appcontext.Context, userID, datasetID uuid.UUID, fields CuratedFields) (*entity.Dataset, error) {
+	var dataset entity.Dataset
+	if err := ctx.DB.Where("id = ?", datasetID).First(&dataset).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dataset: %w", err)
+	}
+
+	edits := fields.edits(dataset.CuratedDescription, dataset.Owner, dataset.Tags)
+	changelog := entity.Changelog{
+		EntityType: "dataset",
+		EntityID:   dataset.ID,
+		EntityName: dataset.Name,
+		ProjectID:  &dataset.ProjectID,
+	}
+	if err := applyEdits(ctx.DB, &dataset, userID, changelog, edits); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.DB.Where("id = ?", datasetID).First(&dataset).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dataset: %w", err)
+	}
+	if len(edits) > 0 {
+		reindex(ctx, utils.DatasetToDocument(&dataset))
+	}
+
+	return &dataset, nil
+}
+
+// EditTable applies a user's edits to a table, logs them to the changelog
+// and re-indexes the table.
+func EditTable(ctx *appcontext.Context, userID, tableID uuid.UUID, fields CuratedFields) (*entity.Table, error) {
+	var table entity.Table
+	if err := ctx.DB.Where("id = ?", tableID).Preload("Columns").First(&table).Error; err != nil {
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+
+	var dataset entity.Dataset
+	if err := ctx.DB.Where("id = ?", table.DatasetID).First(&dataset).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dataset: %w", err)
+	}
+
+	edits := fields.edits(table.CuratedDescription, table.Owner, table.Tags)
+	changelog := entity.Changelog{
+		EntityType: "table",
+		EntityID:   table.ID,
+		EntityName: table.Name,
+		ParentID:   &dataset.ID,
+		ParentName: dataset.Name,
+		ProjectID:  &dataset.ProjectID,
+	}
+	if err := applyEdits(ctx.DB, &table, userID, changelog, edits); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.DB.Where("id = ?", tableID).Preload("Columns").First(&table).Error; err != nil {
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+	if len(edits) > 0 {
+		tableDoc, err := utils.TableToDocument(ctx.DB, &table)
+		if err != nil {
+			ctx.Logger.Error("Failed to create table document", zap.Error(err), zap.String("table_id", table.ID.String()))
+		} else {
+			reindex(ctx, tableDoc)
+		}
+	}
+
+	return &table, nil
+}
+
+// EditColumn applies a user's edits to a column, logs them to the changelog
+// and re-indexes the column.
+func EditColumn(ctx *appcontext.Context, userID, columnID uuid.UUID, fields CuratedFields) (*entity.Column, error) {
+	var column entity.Column
+	if err := ctx.DB.Where("id = ?", columnID).First(&column).Error; err != nil {
+		return nil, fmt.Errorf("failed to get column: %w", err)
+	}
+
+	var table entity.Table
+	if err := ctx.DB.Where("id = ?", column.TableID).First(&table).Error; err != nil {
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+
+	var dataset entity.Dataset
+	if err := ctx.DB.Where("id = ?", table.DatasetID).First(&dataset).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dataset: %w", err)
+	}
+
+	edits := fields.edits(column.CuratedDescription, column.Owner, column.Tags)
+	changelog := entity.Changelog{
+		EntityType:      "column",
+		EntityID:        column.ID,
+		EntityName:      column.Path,
+		ParentID:        &table.ID,
+		ParentName:      table.Name,
+		GrandParentID:   &dataset.ID,
+		GrandParentName: dataset.Name,
+		ProjectID:       &dataset.ProjectID,
+	}
+	if err := applyEdits(ctx.DB, &column, userID, changelog, edits); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.DB.Where("id = ?", columnID).First(&column).Error; err != nil {
+		return nil, fmt.Errorf("failed to get column: %w", err)
+	}
+	if len(edits) > 0 {
+		reindexColumn(ctx, &column)
+	}
+
+	return &column, nil
+}
+
+// applyEdits updates the edited fields of model and stores one changelog
+// entry per field, based on the template, in a single transaction.
+func applyEdits(db *gorm.DB, model interface{}, userID uuid.UUID, template entity.Changelog, edits []curatedEdit) error {
+	if len(edits) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{}
+		for _, edit := range edits {
+			updates[edit.column] = edit.newValue
+		}
+		if err := tx.Model(model).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update %s: %w", template.EntityType, err)
+		}
+
+		for _, edit := range edits {
+			changelog := template
+			changelog.ChangeType = "update"
+			changelog.FieldName = edit.fieldName
+			changelog.OldValue = toJSON(edit.oldValue)
+			changelog.NewValue = toJSON(edit.newValue)
+			changelog.Origin = entity.ChangelogOriginUser
+			changelog.UserID = &userID
+			if err := tx.Create(&changelog).Error; err != nil {
+				return fmt.Errorf("failed to create changelog: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func reindex(ctx *appcontext.Context, document map[string]interface{}) {
+	if _, err := ctx.MeilisearchClient.Index("resources").AddDocuments([]map[string]interface{}{document}, "id"); err != nil {
+		ctx.Logger.Error("Failed to index document", zap.Error(err), zap.Any("id", document["id"]))
+	}
+}
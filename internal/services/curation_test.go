@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestEditTable(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	var orders entity.Table
+	if err := ctx.DB.Where("name = ?", "orders").First(&orders).Error; err != nil {
+		t.Fatalf("failed to get table: %v", err)
+	}
+
+	description := "All orders"
+	owner := " data-platform "
+	tags := []string{"finance", " pii", "finance", ""}
+	table, err := EditTable(ctx, user.ID, orders.ID, CuratedFields{CuratedDescription: &description, Owner: &owner, Tags: &tags})
+	if err != nil {
+		t.Fatalf("EditTable: %v", err)
+	}
+	if table.CuratedDescription != description || table.Owner != "data-platform" || table.Tags != `["finance","pii"]` {
+		t.Errorf("table = %q, %q, %q, want the edits applied", table.CuratedDescription, table.Owner, table.Tags)
+	}
+
+	var changelogs []entity.Changelog
+	if err := ctx.DB.Where("entity_id = ? AND origin = ?", orders.ID, entity.ChangelogOriginUser).Order("field_name").Find(&changelogs).Error; err != nil {
+		t.Fatalf("failed to get changelogs: %v", err)
+	}
+	if len(changelogs) != 3 {
+		t.Fatalf("got %d changelogs, want one per edited field", len(changelogs))
+	}
+	for i, fieldName := range []string{"CuratedDescription", "Owner", "Tags"} {
+		if changelogs[i].FieldName != fieldName || changelogs[i].UserID == nil || *changelogs[i].UserID != user.ID || changelogs[i].SyncID != nil {
+			t.Errorf("changelog %d = %+v, want an edit of %s by the user", i, changelogs[i], fieldName)
+		}
+	}
+
+	// Unchanged fields are not logged again, no tags are stored as empty
+	reordered := []string{"pii", "finance"}
+	if _, err := EditTable(ctx, user.ID, orders.ID, CuratedFields{Owner: &owner, Tags: &reordered}); err != nil {
+		t.Fatalf("EditTable: %v", err)
+	}
+	none := []string{}
+	table, err = EditTable(ctx, user.ID, orders.ID, CuratedFields{Tags: &none})
+	if err != nil {
+		t.Fatalf("EditTable: %v", err)
+	}
+	if table.Tags != "" {
+		t.Errorf("tags = %q, want none", table.Tags)
+	}
+
+	var count int64
+	ctx.DB.Model(&entity.Changelog{}).Where("entity_id = ? AND origin = ?", orders.ID, entity.ChangelogOriginUser).Count(&count)
+	if count != 4 {
+		t.Errorf("got %d changelogs, want 4", count)
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"gorm.io/gorm"
+)
+
+// SchemaDiff is the net difference between the schema of a project at two
+// points in time, grouped by dataset and table.
+type SchemaDiff struct {
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Datasets []DatasetDiff `json:"datasets"`
+}
+
+type DatasetDiff struct {
+	ID      uuid.UUID          `json:"id"`
+	Name    string             `json:"name"`
+	Changes []entity.Changelog `json:"changes"`
+	Tables  []TableDiff        `json:"tables"`
+}
+
+// TableDiff holds the changes to a table and to its columns.
+type TableDiff struct {
+	ID      uuid.UUID          `json:"id"`
+	Name    string             `json:"name"`
+	Changes []entity.Changelog `json:"changes"`
+}
+
+// DiffBetween reconstructs the project at both points in time and compares
+// them, so that changes that cancel each other out in between do not show up.
+func DiffBetween(db *gorm.DB, projectID uuid.UUID, from, to time.Time) (*SchemaDiff, error) {
+	fromState, err := StateAt(db, projectID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toState, err := StateAt(db, projectID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := utils.DiffStates(projectID, nil, fromState, toState, nil, nil)
+
+	diff := &SchemaDiff{From: from, To: to, Datasets: []DatasetDiff{}}
+	datasets := make(map[uuid.UUID]*DatasetDiff)
+	tables := make(map[uuid.UUID]*TableDiff)
+
+	datasetDiff := func(id uuid.UUID, name string) *DatasetDiff {
+		if datasets[id] == nil {
+			datasets[id] = &DatasetDiff{ID: id, Name: name, Changes: []entity.Changelog{}, Tables: []TableDiff{}}
+		}
+		return datasets[id]
+	}
+	tableDiff := func(id uuid.UUID, name string) *TableDiff {
+		if tables[id] == nil {
+			tables[id] = &TableDiff{ID: id, Name: name, Changes: []entity.Changelog{}}
+		}
+		return tables[id]
+	}
+	tableDatasets := make(map[uuid.UUID]uuid.UUID)
+
+	for _, change := range changes {
+		switch change.EntityType {
+		case "dataset":
+			ds := datasetDiff(change.EntityID, change.EntityName)
+			ds.Changes = append(ds.Changes, change)
+		case "table":
+			datasetDiff(*change.ParentID, change.ParentName)
+			tbl := tableDiff(change.EntityID, change.EntityName)
+			tbl.Changes = append(tbl.Changes, change)
+			tableDatasets[change.EntityID] = *change.ParentID
+		case "column":
+			datasetDiff(*change.GrandParentID, change.GrandParentName)
+			tbl := tableDiff(*change.ParentID, change.ParentName)
+			tbl.Changes = append(tbl.Changes, change)
+			tableDatasets[*change.ParentID] = *change.GrandParentID
+		}
+	}
+
+	for tableID, tbl := range tables {
+		sortChanges(tbl.Changes)
+		ds := datasets[tableDatasets[tableID]]
+		ds.Tables = append(ds.Tables, *tbl)
+	}
+	for _, ds := range datasets {
+		sortChanges(ds.Changes)
+		sort.Slice(ds.Tables, func(i, j int) bool { return ds.Tables[i].Name < ds.Tables[j].Name })
+		diff.Datasets = append(diff.Datasets, *ds)
+	}
+	sort.Slice(diff.Datasets, func(i, j int) bool { return diff.Datasets[i].Name < diff.Datasets[j].Name })
+
+	return diff, nil
+}
+
+var entityTypeOrder = map[string]int{"dataset": 0, "table": 1, "column": 2}
+
+func sortChanges(changes []entity.Changelog) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.EntityType != b.EntityType {
+			return entityTypeOrder[a.EntityType] < entityTypeOrder[b.EntityType]
+		}
+		if a.EntityName != b.EntityName {
+			return a.EntityName < b.EntityName
+		}
+		return a.FieldName < b.FieldName
+	})
+}
+
+// UnifiedText renders the diff in the style of a unified diff, with one hunk
+// per dataset and table.
+func (d *SchemaDiff) UnifiedText(projectName string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "--- %s\t%s\n", projectName, d.From.Format(time.RFC3339))
+	fmt.Fprintf(&b, "+++ %s\t%s\n", projectName, d.To.Format(time.RFC3339))
+
+	for _, ds := range d.Datasets {
+		if len(ds.Changes) > 0 {
+			fmt.Fprintf(&b, "@@ %s @@\n", ds.Name)
+			writeChanges(&b, ds.Changes)
+		}
+		for _, tbl := range ds.Tables {
+			fmt.Fprintf(&b, "@@ %s.%s @@\n", ds.Name, tbl.Name)
+			writeChanges(&b, tbl.Changes)
+		}
+	}
+
+	return b.String()
+}
+
+func writeChanges(b *strings.Builder, changes []entity.Changelog) {
+	for _, change := range changes {
+		switch change.ChangeType {
+		case "insert", "restore":
+			fmt.Fprintf(b, "+%s %s\n", change.EntityType, change.EntityName)
+		case "delete", "exclude":
+			fmt.Fprintf(b, "-%s %s\n", change.EntityType, change.EntityName)
+		case "rename":
+			fmt.Fprintf(b, "-%s %s\n", change.EntityType, diffValue(change.OldValue))
+			fmt.Fprintf(b, "+%s %s\n", change.EntityType, change.EntityName)
+		case "update":
+			fmt.Fprintf(b, "-%s %s %s: %s\n", change.EntityType, change.EntityName, change.FieldName, diffValue(change.OldValue))
+			fmt.Fprintf(b, "+%s %s %s: %s\n", change.EntityType, change.EntityName, change.FieldName, diffValue(change.NewValue))
+		}
+	}
+}
+
+// diffValue unquotes string values stored in the changelog, other values are
+// shown as JSON.
+func diffValue(value string) string {
+	var s string
+	if err := json.Unmarshal([]byte(value), &s); err == nil {
+		return s
+	}
+	return value
+}
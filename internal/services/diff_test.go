@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestUnifiedText(t *testing.T) {
+	diff := &SchemaDiff{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Datasets: []DatasetDiff{
+			{
+				Name:    "marketing",
+				Changes: []entity.Changelog{{ChangeType: "insert", EntityType: "dataset", EntityName: "marketing"}},
+			},
+			{
+				Name: "sales",
+				Tables: []TableDiff{
+					{Name: "orders", Changes: []entity.Changelog{
+						{ChangeType: "update", EntityType: "table", EntityName: "orders", FieldName: "RowCount", OldValue: "10", NewValue: "20"},
+						{ChangeType: "delete", EntityType: "column", EntityName: "note"},
+						{ChangeType: "rename", EntityType: "column", EntityName: "total", OldValue: `"amount"`, NewValue: `"total"`},
+						{ChangeType: "update", EntityType: "column", EntityName: "total", FieldName: "Type", OldValue: `"INT64"`, NewValue: `"NUMERIC"`},
+					}},
+					{Name: "refunds", Changes: []entity.Changelog{{ChangeType: "exclude", EntityType: "table", EntityName: "refunds"}}},
+				},
+			},
+		},
+	}
+
+	want := "--- shop\t2024-01-01T00:00:00Z\n" +
+		"+++ shop\t2024-02-01T00:00:00Z\n" +
+		"@@ marketing @@\n" +
+		"+dataset marketing\n" +
+		"@@ sales.orders @@\n" +
+		"-table orders RowCount: 10\n" +
+		"+table orders RowCount: 20\n" +
+		"-column note\n" +
+		"-column amount\n" +
+		"+column total\n" +
+		"-column total Type: INT64\n" +
+		"+column total Type: NUMERIC\n" +
+		"@@ sales.refunds @@\n" +
+		"-table refunds\n"
+
+	if got := diff.UnifiedText("shop"); got != want {
+		t.Errorf("UnifiedText =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDiffBetween(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+	from := time.Now()
+
+	// A description that is changed and changed back does not show up
+	orders := conn.Table("sales", "orders")
+	description := orders.Description
+	orders.Description = "Temporary"
+	orders.Columns[1].Type = "NUMERIC"
+	mustSync(t, ctx, project.ID, conn)
+	orders.Description = description
+	conn.AddTable("sales", customersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	diff, err := DiffBetween(ctx.DB, project.ID, from, time.Now())
+	if err != nil {
+		t.Fatalf("DiffBetween: %v", err)
+	}
+
+	if len(diff.Datasets) != 1 || diff.Datasets[0].Name != "sales" || len(diff.Datasets[0].Tables) != 2 {
+		t.Fatalf("diff = %+v, want both tables of sales", diff.Datasets)
+	}
+	customers, orderChanges := diff.Datasets[0].Tables[0], diff.Datasets[0].Tables[1]
+	if customers.Name != "customers" || len(customers.Changes) != 1 || customers.Changes[0].ChangeType != "insert" {
+		t.Errorf("customers changes = %+v, want an insert", customers.Changes)
+	}
+	if len(orderChanges.Changes) != 1 || orderChanges.Changes[0].EntityName != "amount" || orderChanges.Changes[0].FieldName != "Type" {
+		t.Errorf("orders changes = %+v, want the type change of amount", orderChanges.Changes)
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// digestRetryDelay is how long a digest that failed to send is postponed.
+	digestRetryDelay = time.Hour
+	// digestLease is how long a claimed subscription is hidden from other
+	// processes while its digest is sent.
+	digestLease = 10 * time.Minute
+)
+
+// Digest summarizes the changes to a project over a period of time.
+type Digest struct {
+	Project        entity.Project     `json:"project"`
+	From           time.Time          `json:"from"`
+	To             time.Time          `json:"to"`
+	NewTables      []entity.Changelog `json:"new_tables"`
+	DroppedColumns []entity.Changelog `json:"dropped_columns"`
+	TypeChanges    []entity.Changelog `json:"type_changes"`
+	FailedSyncs    []entity.Sync      `json:"failed_syncs"`
+}
+
+func (d *Digest) Empty() bool {
+	return len(d.NewTables) == 0 && len(d.DroppedColumns) == 0 && len(d.TypeChanges) == 0 && len(d.FailedSyncs) == 0
+}
+
+func ValidDigestFrequency(frequency string) bool {
+	return frequency == entity.DigestFrequencyDaily || frequency == entity.DigestFrequencyWeekly
+}
+
+// DigestPeriod returns the time between two digests of the given frequency.
+func DigestPeriod(frequency string) time.Duration {
+	if frequency == entity.DigestFrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// BuildDigest aggregates the changelog and syncs of a project between from
+// and to.
+func BuildDigest(db *gorm.DB, projectID uuid.UUID, from, to time.Time) (*Digest, error) {
+	digest := &Digest{From: from, To: to}
+
+	if err := db.Where("id = ?", projectID).First(&digest.Project).Error; err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	changes := db.Where("project_id = ? AND created_at >= ? AND created_at < ?", projectID, from, to).Order("parent_name, entity_name")
+
+	if err := changes.Session(&gorm.Session{}).
+		Where("entity_type = ? AND change_type IN ?", "table", []string{"insert", "restore"}).
+		Find(&digest.NewTables).Error; err != nil {
+		return nil, fmt.Errorf("failed to get new tables: %w", err)
+	}
+
+	if err := changes.Session(&gorm.Session{}).
+		Where("entity_type = ? AND change_type = ?", "column", "delete").
+		Find(&digest.DroppedColumns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dropped columns: %w", err)
+	}
+
+	if err := changes.Session(&gorm.Session{}).
+		Where("entity_type = ? AND change_type = ? AND field_name = ?", "column", "update", "Type").
+		Find(&digest.TypeChanges).Error; err != nil {
+		return nil, fmt.Errorf("failed to get type changes: %w", err)
+	}
+
+	if err := db.Where("project_id = ? AND status = ? AND created_at >= ? AND created_at < ?", projectID, entity.SyncStatusFailed, from, to).
+		Order("created_at").
+		Find(&digest.FailedSyncs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get failed syncs: %w", err)
+	}
+
+	return digest, nil
+}
+
+// RunDigests sends due digests every interval until stop is done.
+func RunDigests(ctx *appcontext.Context, stop context.Context, interval time.Duration, mailer Mailer) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := sendDueDigests(ctx, mailer); err != nil {
+			ctx.Logger.Error("Failed to send digests", zap.Error(err))
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDueDigests sends one digest at a time. A subscription is claimed by
+// moving its retry_at past digestLease before its digest is sent, so several
+// processes can send digests without sending one twice and no row lock is
+// held while talking to the mail server. A digest that fails to send is
+// retried after digestRetryDelay, one whose process crashed after the lease.
+func sendDueDigests(ctx *appcontext.Context, mailer Mailer) error {
+	for {
+		sent, err := sendNextDigest(ctx, mailer)
+		if err != nil {
+			return err
+		}
+		if !sent {
+			return nil
+		}
+	}
+}
+
+func sendNextDigest(ctx *appcontext.Context, mailer Mailer) (bool, error) {
+	now := time.Now()
+
+	var subscription entity.DigestSubscription
+	err := ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(last_sent_at IS NULL OR (frequency = ? AND last_sent_at <= ?) OR (frequency = ? AND last_sent_at <= ?))",
+				entity.DigestFrequencyDaily, now.Add(-DigestPeriod(entity.DigestFrequencyDaily)),
+				entity.DigestFrequencyWeekly, now.Add(-DigestPeriod(entity.DigestFrequencyWeekly))).
+			Where("retry_at IS NULL OR retry_at <= ?", now).
+			Order("last_sent_at NULLS FIRST").
+			First(&subscription).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&subscription).Update("retry_at", now.Add(digestLease)).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest subscription: %w", err)
+	}
+
+	updates := map[string]interface{}{"last_sent_at": now, "retry_at": nil}
+	if err := sendDigest(ctx.DB, mailer, &subscription, now); err != nil {
+		ctx.Logger.Error("Failed to send digest", zap.Error(err), zap.String("subscription_id", subscription.ID.String()))
+		// Postpone the subscription, so that it does not block the others
+		updates = map[string]interface{}{"retry_at": now.Add(digestRetryDelay)}
+	}
+
+	if err := ctx.DB.Model(&subscription).Updates(updates).Error; err != nil {
+		return false, fmt.Errorf("failed to update digest subscription: %w", err)
+	}
+
+	return true, nil
+}
+
+// sendDigest emails the changes since the last digest to the subscriber, or
+// over the subscription's period for the first one. Nothing is sent if
+// nothing changed, or if the user no longer has access to the project.
+func sendDigest(db *gorm.DB, mailer Mailer, subscription *entity.DigestSubscription, now time.Time) error {
+	from := now.Add(-DigestPeriod(subscription.Frequency))
+	if subscription.LastSentAt != nil {
+		from = *subscription.LastSentAt
+	}
+
+	var user entity.User
+	if err := db.Where("id = ?", subscription.UserID).First(&user).Error; err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	digest, err := BuildDigest(db, subscription.ProjectID, from, now)
+	if err != nil {
+		return err
+	}
+
+	if user.CompanyID == nil || *user.CompanyID != digest.Project.CompanyID || digest.Empty() {
+		return nil
+	}
+
+	return mailer.Send(digestMessage(&user, digest, subscription.Frequency))
+}
+
+func digestMessage(user *entity.User, digest *Digest, frequency string) MailMessage {
+	var text, body strings.Builder
+
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Fprintf(&text, "%s (%d)\n", title, len(lines))
+		fmt.Fprintf(&body, `<h2 style="color: #2c3e50;">%s (%d)</h2><ul>`, html.EscapeString(title), len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(&text, "  - %s\n", line)
+			fmt.Fprintf(&body, "<li>%s</li>", html.EscapeString(line))
+		}
+		text.WriteString("\n")
+		body.WriteString("</ul>")
+	}
+
+	var lines []string
+	for _, change := range digest.NewTables {
+		lines = append(lines, change.ParentName+"."+change.EntityName)
+	}
+	section("New tables", lines)
+
+	lines = nil
+	for _, change := range digest.DroppedColumns {
+		lines = append(lines, change.GrandParentName+"."+change.ParentName+"."+change.EntityName)
+	}
+	section("Dropped columns", lines)
+
+	lines = nil
+	for _, change := range digest.TypeChanges {
+		lines = append(lines, fmt.Sprintf("%s.%s.%s: %s to %s", change.GrandParentName, change.ParentName, change.EntityName, diffValue(change.OldValue), diffValue(change.NewValue)))
+	}
+	section("Type changes", lines)
+
+	lines = nil
+	for _, sync := range digest.FailedSyncs {
+		lines = append(lines, fmt.Sprintf("%s: %s", sync.CreatedAt.UTC().Format(time.RFC1123), sync.Error))
+	}
+	section("Failed syncs", lines)
+
+	period := "Daily"
+	if frequency == entity.DigestFrequencyWeekly {
+		period = "Weekly"
+	}
+	subject := fmt.Sprintf("%s digest for %s", period, digest.Project.Name)
+	projectURL := fmt.Sprintf("%s/projects/%s", os.Getenv("FRONTEND_HOST"), digest.Project.ID)
+
+	plainText := fmt.Sprintf("Changes to %s from %s to %s:\n\n%sSee the full changelog at %s\n\nYou receive this digest because you subscribed to it in Katalog.",
+		digest.Project.Name, digest.From.UTC().Format(time.RFC1123), digest.To.UTC().Format(time.RFC1123), text.String(), projectURL)
+
+	htmlContent := fmt.Sprintf(`
+		<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+			<h1 style="color: #2c3e50;">%s</h1>
+			<p>Changes from %s to %s.</p>
+			%s
+			<a href="%s" style="display: inline-block; background-color: #2563eb; color: #ffffff; text-decoration: none; padding: 12px 24px; border-radius: 4px; font-weight: bold; margin-top: 20px;">View changelog</a>
+			<p style="margin-top: 30px; font-size: 12px; color: #7f8c8d;">You receive this digest because you subscribed to it in Katalog.</p>
+		</div>
+		`, html.EscapeString(subject), digest.From.UTC().Format(time.RFC1123), digest.To.UTC().Format(time.RFC1123), body.String(), html.EscapeString(projectURL))
+
+	return MailMessage{
+		ToName:    user.Name,
+		ToAddress: user.Email,
+		Subject:   subject,
+		PlainText: plainText,
+		HTML:      htmlContent,
+	}
+}
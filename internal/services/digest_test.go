@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+// fakeMailer records the emails it is asked to send and fails with err.
+type fakeMailer struct {
+	sent []MailMessage
+	err  error
+	// onSend is called before an email is sent.
+	onSend func()
+}
+
+func (m *fakeMailer) Send(message MailMessage) error {
+	if m.onSend != nil {
+		m.onSend()
+	}
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+func createDigestSubscription(t *testing.T, ctx *appcontext.Context) (entity.User, entity.DigestSubscription) {
+	t.Helper()
+
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+	conn.AddTable("sales", customersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	subscription := entity.DigestSubscription{UserID: user.ID, ProjectID: project.ID, Frequency: entity.DigestFrequencyDaily}
+	if err := ctx.DB.Create(&subscription).Error; err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	return user, subscription
+}
+
+func storedSubscription(t *testing.T, ctx *appcontext.Context, id interface{}) entity.DigestSubscription {
+	t.Helper()
+
+	var subscription entity.DigestSubscription
+	if err := ctx.DB.Where("id = ?", id).First(&subscription).Error; err != nil {
+		t.Fatalf("failed to get subscription: %v", err)
+	}
+	return subscription
+}
+
+func TestSendDueDigests(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	user, subscription := createDigestSubscription(t, ctx)
+
+	mailer := &fakeMailer{}
+	// The claim is committed before the email is sent, so other processes
+	// skip the subscription without waiting on a lock
+	mailer.onSend = func() {
+		if claimed := storedSubscription(t, ctx, subscription.ID); claimed.RetryAt == nil {
+			t.Errorf("subscription is not claimed while its digest is sent")
+		}
+	}
+
+	if err := sendDueDigests(ctx, mailer); err != nil {
+		t.Fatalf("sendDueDigests: %v", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].ToAddress != user.Email {
+		t.Fatalf("sent %+v, want one digest to %s", mailer.sent, user.Email)
+	}
+
+	stored := storedSubscription(t, ctx, subscription.ID)
+	if stored.LastSentAt == nil || stored.RetryAt != nil {
+		t.Errorf("subscription sent at %v, retry at %v, want sent and no retry", stored.LastSentAt, stored.RetryAt)
+	}
+
+	// The digest is not due again before the next period
+	if err := sendDueDigests(ctx, mailer); err != nil {
+		t.Fatalf("sendDueDigests: %v", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Errorf("sent %d digests, want 1", len(mailer.sent))
+	}
+}
+
+func TestSendDueDigestsFailure(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	_, subscription := createDigestSubscription(t, ctx)
+
+	mailer := &fakeMailer{err: errors.New("mail server unavailable")}
+	if err := sendDueDigests(ctx, mailer); err != nil {
+		t.Fatalf("sendDueDigests: %v", err)
+	}
+
+	stored := storedSubscription(t, ctx, subscription.ID)
+	if stored.LastSentAt != nil || stored.RetryAt == nil || stored.RetryAt.Before(subscription.CreatedAt.Add(digestRetryDelay/2)) {
+		t.Errorf("subscription sent at %v, retry at %v, want a retry in %s", stored.LastSentAt, stored.RetryAt, digestRetryDelay)
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"gorm.io/gorm"
+)
+
+type filterRule struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+func (r filterRule) matches(name string) bool {
+	if r.regex != nil {
+		return r.regex.MatchString(name)
+	}
+	matched, _ := path.Match(r.glob, name)
+	return matched
+}
+
+// SyncFilters decides which datasets and tables a sync catalogs. An entity
+// is cataloged if it matches an include rule, or there are no include rules
+// for its type, and it matches no exclude rule.
+type SyncFilters struct {
+	datasetIncludes []filterRule
+	datasetExcludes []filterRule
+	tableIncludes   []filterRule
+	tableExcludes   []filterRule
+}
+
+// ValidateFilterPattern reports whether pattern is a valid pattern of the given type.
+func ValidateFilterPattern(patternType, pattern string) error {
+	_, err := newFilterRule(patternType, pattern)
+	return err
+}
+
+func newFilterRule(patternType, pattern string) (filterRule, error) {
+	switch patternType {
+	case entity.SyncFilterPatternGlob:
+		if _, err := path.Match(pattern, ""); err != nil {
+			return filterRule{}, fmt.Errorf("invalid glob pattern: %w", err)
+		}
+		return filterRule{glob: pattern}, nil
+	case entity.SyncFilterPatternRegex:
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return filterRule{}, fmt.Errorf("invalid regex pattern: %w", err)
+		}
+		return filterRule{regex: regex}, nil
+	default:
+		return filterRule{}, fmt.Errorf("unsupported pattern type: %s", patternType)
+	}
+}
+
+// LoadSyncFilters reads the filter rules of a project.
+func LoadSyncFilters(db *gorm.DB, projectID uuid.UUID) (*SyncFilters, error) {
+	var filters []entity.SyncFilter
+	if err := db.Where("project_id = ?", projectID).Find(&filters).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch sync filters: %w", err)
+	}
+
+	return newSyncFilters(filters)
+}
+
+func newSyncFilters(filters []entity.SyncFilter) (*SyncFilters, error) {
+	syncFilters := &SyncFilters{}
+	for _, filter := range filters {
+		rule, err := newFilterRule(filter.PatternType, filter.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", filter.ID, err)
+		}
+
+		switch {
+		case filter.EntityType == "dataset" && filter.Action == entity.SyncFilterActionInclude:
+			syncFilters.datasetIncludes = append(syncFilters.datasetIncludes, rule)
+		case filter.EntityType == "dataset" && filter.Action == entity.SyncFilterActionExclude:
+			syncFilters.datasetExcludes = append(syncFilters.datasetExcludes, rule)
+		case filter.EntityType == "table" && filter.Action == entity.SyncFilterActionInclude:
+			syncFilters.tableIncludes = append(syncFilters.tableIncludes, rule)
+		case filter.EntityType == "table" && filter.Action == entity.SyncFilterActionExclude:
+			syncFilters.tableExcludes = append(syncFilters.tableExcludes, rule)
+		}
+	}
+
+	return syncFilters, nil
+}
+
+func (f *SyncFilters) DatasetAllowed(dataset string) bool {
+	return allowed(f.datasetIncludes, f.datasetExcludes, dataset)
+}
+
+// TableAllowed matches table rules against the table name and against the
+// qualified "dataset.table" name.
+func (f *SyncFilters) TableAllowed(dataset, table string) bool {
+	return allowed(f.tableIncludes, f.tableExcludes, table, dataset+"."+table)
+}
+
+func allowed(includes, excludes []filterRule, names ...string) bool {
+	if len(includes) > 0 && !anyMatches(includes, names) {
+		return false
+	}
+	return !anyMatches(excludes, names)
+}
+
+func anyMatches(rules []filterRule, names []string) bool {
+	for _, rule := range rules {
+		for _, name := range names {
+			if rule.matches(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
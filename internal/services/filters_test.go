@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func filter(entityType, action, patternType, pattern string) entity.SyncFilter {
+	return entity.SyncFilter{EntityType: entityType, Action: action, PatternType: patternType, Pattern: pattern}
+}
+
+func TestSyncFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []entity.SyncFilter
+		datasets map[string]bool
+		tables   map[string]bool
+	}{
+		{
+			name:     "no filters",
+			datasets: map[string]bool{"sales": true},
+			tables:   map[string]bool{"sales.orders": true},
+		},
+		{
+			name: "dataset exclude glob",
+			filters: []entity.SyncFilter{
+				filter("dataset", entity.SyncFilterActionExclude, entity.SyncFilterPatternGlob, "tmp_*"),
+			},
+			datasets: map[string]bool{"sales": true, "tmp_scratch": false, "scratch_tmp": true},
+		},
+		{
+			name: "dataset include regex",
+			filters: []entity.SyncFilter{
+				filter("dataset", entity.SyncFilterActionInclude, entity.SyncFilterPatternRegex, "^(sales|marketing)$"),
+			},
+			datasets: map[string]bool{"sales": true, "marketing": true, "sales_archive": false},
+		},
+		{
+			name: "exclude wins over include",
+			filters: []entity.SyncFilter{
+				filter("dataset", entity.SyncFilterActionInclude, entity.SyncFilterPatternGlob, "sales*"),
+				filter("dataset", entity.SyncFilterActionExclude, entity.SyncFilterPatternGlob, "*_archive"),
+			},
+			datasets: map[string]bool{"sales": true, "sales_archive": false, "marketing": false},
+		},
+		{
+			name: "table glob matches the table name",
+			filters: []entity.SyncFilter{
+				filter("table", entity.SyncFilterActionExclude, entity.SyncFilterPatternGlob, "*_backup"),
+			},
+			tables: map[string]bool{"sales.orders": true, "sales.orders_backup": false, "marketing.leads_backup": false},
+		},
+		{
+			name: "table glob matches the qualified name",
+			filters: []entity.SyncFilter{
+				filter("table", entity.SyncFilterActionExclude, entity.SyncFilterPatternGlob, "staging.*"),
+			},
+			tables: map[string]bool{"staging.orders": false, "sales.orders": true},
+		},
+		{
+			name: "table include regex",
+			filters: []entity.SyncFilter{
+				filter("table", entity.SyncFilterActionInclude, entity.SyncFilterPatternRegex, `^sales\.(orders|customers)$`),
+			},
+			tables: map[string]bool{"sales.orders": true, "sales.customers": true, "sales.refunds": false, "marketing.orders": false},
+		},
+		{
+			name: "table rules do not filter datasets",
+			filters: []entity.SyncFilter{
+				filter("table", entity.SyncFilterActionExclude, entity.SyncFilterPatternGlob, "*"),
+			},
+			datasets: map[string]bool{"sales": true},
+			tables:   map[string]bool{"sales.orders": false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filters, err := newSyncFilters(test.filters)
+			if err != nil {
+				t.Fatalf("newSyncFilters: %v", err)
+			}
+
+			for dataset, want := range test.datasets {
+				if got := filters.DatasetAllowed(dataset); got != want {
+					t.Errorf("DatasetAllowed(%q) = %v, want %v", dataset, got, want)
+				}
+			}
+			for qualified, want := range test.tables {
+				dataset, table, _ := strings.Cut(qualified, ".")
+				if got := filters.TableAllowed(dataset, table); got != want {
+					t.Errorf("TableAllowed(%q, %q) = %v, want %v", dataset, table, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFilterPattern(t *testing.T) {
+	tests := []struct {
+		patternType string
+		pattern     string
+		valid       bool
+	}{
+		{entity.SyncFilterPatternGlob, "tmp_*", true},
+		{entity.SyncFilterPatternGlob, "[a-", false},
+		{entity.SyncFilterPatternRegex, "^tmp_.*$", true},
+		{entity.SyncFilterPatternRegex, "(", false},
+		{"wildcard", "*", false},
+	}
+
+	for _, test := range tests {
+		err := ValidateFilterPattern(test.patternType, test.pattern)
+		if (err == nil) != test.valid {
+			t.Errorf("ValidateFilterPattern(%q, %q) = %v, want valid %v", test.patternType, test.pattern, err, test.valid)
+		}
+	}
+}
+
+func TestSyncProjectExcludes(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	conn.AddTable("sales", customersTable())
+	conn.AddTable("tmp_scratch", customersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	for _, f := range []entity.SyncFilter{
+		filter("dataset", entity.SyncFilterActionExclude, entity.SyncFilterPatternGlob, "tmp_*"),
+		filter("table", entity.SyncFilterActionExclude, entity.SyncFilterPatternGlob, "sales.customers"),
+	} {
+		f.ProjectID = project.ID
+		if err := ctx.DB.Create(&f).Error; err != nil {
+			t.Fatalf("failed to create filter: %v", err)
+		}
+	}
+
+	// Excluded datasets and tables are not read at all
+	conn.DescribeErr["sales.customers"] = errors.New("excluded table was described")
+	conn.ListTablesErr["tmp_scratch"] = errors.New("excluded dataset was listed")
+
+	sync, _ := mustSync(t, ctx, project.ID, conn)
+	changelogs := syncChangelogs(t, ctx.DB, sync.ID)
+
+	if findChange(changelogs, "exclude", "dataset", "tmp_scratch", "") == nil {
+		t.Errorf("no dataset exclude in %+v", changelogs)
+	}
+	if findChange(changelogs, "exclude", "table", "customers", "") == nil {
+		t.Errorf("no table exclude in %+v", changelogs)
+	}
+	if len(changelogs) != 2 {
+		t.Errorf("got %d changelogs, want 2: %+v", len(changelogs), changelogs)
+	}
+
+	var tables []string
+	ctx.DB.Model(&entity.Table{}).Order("name").Pluck("name", &tables)
+	if len(tables) != 1 || tables[0] != "orders" {
+		t.Errorf("tables = %v, want [orders]", tables)
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"gorm.io/gorm"
+)
+
+// SyncTime returns the point in time right after the given sync of the
+// project, including the changelog it recorded.
+func SyncTime(db *gorm.DB, projectID, syncID uuid.UUID) (time.Time, error) {
+	var sync entity.Sync
+	if err := db.Where("id = ? AND project_id = ?", syncID, projectID).First(&sync).Error; err != nil {
+		return time.Time{}, fmt.Errorf("failed to get sync: %w", err)
+	}
+
+	at := sync.CreatedAt
+	if sync.FinishedAt != nil {
+		at = *sync.FinishedAt
+	}
+
+	var lastChange *time.Time
+	if err := db.Model(&entity.Changelog{}).Select("MAX(created_at)").Where("sync_id = ?", syncID).Scan(&lastChange).Error; err != nil {
+		return time.Time{}, fmt.Errorf("failed to get changelog of sync: %w", err)
+	}
+	if lastChange != nil && lastChange.After(at) {
+		at = *lastChange
+	}
+
+	return at, nil
+}
+
+// stateScope selects the rows StateAt starts from and the changelog entries
+// it reverses.
+type stateScope struct {
+	datasets, tables, columns, changelogs func(*gorm.DB) *gorm.DB
+}
+
+// StateAt reconstructs the datasets, tables and columns of a project as they
+// were at the given time. It starts from the stored rows, including
+// soft-deleted ones, and reverses every changelog entry recorded after that
+// time: field updates are rolled back to their old value, and the first
+// lifecycle change after that time tells whether the entity existed. Entities
+// without such a change, such as the tables of a deleted dataset, existed if
+// they were created before and not deleted until then.
+func StateAt(db *gorm.DB, projectID uuid.UUID, at time.Time) ([]entity.Dataset, error) {
+	return stateAt(db, stateScope{
+		datasets: func(db *gorm.DB) *gorm.DB { return db.Where("project_id = ?", projectID) },
+		tables: func(db *gorm.DB) *gorm.DB {
+			return db.Where("dataset_id IN (SELECT id FROM datasets WHERE project_id = ?)", projectID)
+		},
+		columns: func(db *gorm.DB) *gorm.DB {
+			return db.Where("table_id IN (SELECT id FROM tables WHERE dataset_id IN (SELECT id FROM datasets WHERE project_id = ?))", projectID)
+		},
+		changelogs: func(db *gorm.DB) *gorm.DB { return db.Where("project_id = ?", projectID) },
+	}, at)
+}
+
+// DatasetStateAt reconstructs the tables of a dataset as they were at the
+// given time, see StateAt. It returns no tables if the dataset did not exist
+// then.
+func DatasetStateAt(db *gorm.DB, datasetID uuid.UUID, at time.Time) ([]entity.Table, error) {
+	state, err := stateAt(db, stateScope{
+		datasets: func(db *gorm.DB) *gorm.DB { return db.Where("id = ?", datasetID) },
+		tables:   func(db *gorm.DB) *gorm.DB { return db.Where("dataset_id = ?", datasetID) },
+		columns: func(db *gorm.DB) *gorm.DB {
+			return db.Where("table_id IN (SELECT id FROM tables WHERE dataset_id = ?)", datasetID)
+		},
+		changelogs: func(db *gorm.DB) *gorm.DB {
+			return db.Where("entity_id = ? OR parent_id = ? OR grand_parent_id = ?", datasetID, datasetID, datasetID)
+		},
+	}, at)
+	if err != nil || len(state) == 0 {
+		return nil, err
+	}
+	return state[0].Tables, nil
+}
+
+// TableStateAt reconstructs the columns of a table as they were at the given
+// time, see StateAt. It returns no columns if the table did not exist then.
+func TableStateAt(db *gorm.DB, tableID uuid.UUID, at time.Time) ([]entity.Column, error) {
+	var table entity.Table
+	if err := db.Unscoped().Where("id = ?", tableID).First(&table).Error; err != nil {
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+
+	state, err := stateAt(db, stateScope{
+		datasets: func(db *gorm.DB) *gorm.DB { return db.Where("id = ?", table.DatasetID) },
+		tables:   func(db *gorm.DB) *gorm.DB { return db.Where("id = ?", tableID) },
+		columns:  func(db *gorm.DB) *gorm.DB { return db.Where("table_id = ?", tableID) },
+		changelogs: func(db *gorm.DB) *gorm.DB {
+			return db.Where("entity_id IN ? OR parent_id = ?", []uuid.UUID{table.DatasetID, tableID}, tableID)
+		},
+	}, at)
+	if err != nil || len(state) == 0 || len(state[0].Tables) == 0 {
+		return nil, err
+	}
+	return state[0].Tables[0].Columns, nil
+}
+
+func stateAt(db *gorm.DB, scope stateScope, at time.Time) ([]entity.Dataset, error) {
+	var datasets []entity.Dataset
+	if err := db.Unscoped().Scopes(scope.datasets).Find(&datasets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get datasets: %w", err)
+	}
+
+	var tables []entity.Table
+	if err := db.Unscoped().Scopes(scope.tables).Find(&tables).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tables: %w", err)
+	}
+
+	var columns []entity.Column
+	if err := db.Unscoped().Scopes(scope.columns).Find(&columns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	var changelogs []entity.Changelog
+	if err := db.Scopes(scope.changelogs).Where("created_at > ?", at).Order("created_at").Find(&changelogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get changelogs: %w", err)
+	}
+
+	existed := make(map[uuid.UUID]bool)
+	rolledBack := make(map[string]bool)
+	entities := make(map[uuid.UUID]interface{})
+	for i := range datasets {
+		entities[datasets[i].ID] = &datasets[i]
+	}
+	for i := range tables {
+		entities[tables[i].ID] = &tables[i]
+	}
+	for i := range columns {
+		entities[columns[i].ID] = &columns[i]
+	}
+
+	for _, changelog := range changelogs {
+		switch changelog.ChangeType {
+		case "update":
+			// The oldest update after the point in time holds the value back then
+			key := changelog.EntityID.String() + "." + changelog.FieldName
+			if rolledBack[key] {
+				continue
+			}
+			rolledBack[key] = true
+			if model, ok := entities[changelog.EntityID]; ok {
+				setField(model, changelog.FieldName, changelog.OldValue)
+			}
+		default:
+			if _, seen := existed[changelog.EntityID]; !seen {
+				existed[changelog.EntityID] = changelog.ChangeType == "delete" || changelog.ChangeType == "exclude"
+			}
+		}
+	}
+
+	existedAt := func(id uuid.UUID, model gorm.Model) bool {
+		if exists, ok := existed[id]; ok {
+			return exists
+		}
+		return !model.CreatedAt.After(at) && (!model.DeletedAt.Valid || model.DeletedAt.Time.After(at))
+	}
+
+	columnsByTable := make(map[uuid.UUID][]entity.Column)
+	for _, column := range columns {
+		if existedAt(column.ID, column.Model) {
+			columnsByTable[column.TableID] = append(columnsByTable[column.TableID], column)
+		}
+	}
+
+	tablesByDataset := make(map[uuid.UUID][]entity.Table)
+	for _, table := range tables {
+		if existedAt(table.ID, table.Model) {
+			table.Columns = columnsByTable[table.ID]
+			sort.Slice(table.Columns, func(i, j int) bool { return table.Columns[i].Path < table.Columns[j].Path })
+			tablesByDataset[table.DatasetID] = append(tablesByDataset[table.DatasetID], table)
+		}
+	}
+
+	var state []entity.Dataset
+	for _, dataset := range datasets {
+		if existedAt(dataset.ID, dataset.Model) {
+			dataset.Tables = tablesByDataset[dataset.ID]
+			sort.Slice(dataset.Tables, func(i, j int) bool { return dataset.Tables[i].Name < dataset.Tables[j].Name })
+			state = append(state, dataset)
+		}
+	}
+	sort.Slice(state, func(i, j int) bool { return state[i].Name < state[j].Name })
+
+	return state, nil
+}
+
+// setField sets a field of an entity to a value as stored in the changelog.
+func setField(model interface{}, fieldName, value string) {
+	field := reflect.ValueOf(model).Elem().FieldByName(fieldName)
+	if !field.IsValid() || !field.CanSet() {
+		return
+	}
+	target := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(value), target.Interface()); err != nil {
+		return
+	}
+	field.Set(target.Elem())
+}
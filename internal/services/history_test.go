@@ -0,0 +1,77 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestStateAtScopes(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	conn.AddTable("marketing", customersTable())
+	mustSync(t, ctx, project.ID, conn)
+	at := time.Now()
+
+	orders := conn.Table("sales", "orders")
+	orders.Columns[1].Type = "STRING"
+	orders.Columns = orders.Columns[:2]
+	conn.RemoveTable("marketing", "customers")
+	mustSync(t, ctx, project.ID, conn)
+
+	var sales, marketing entity.Dataset
+	if err := ctx.DB.Unscoped().Where("name = ?", "sales").First(&sales).Error; err != nil {
+		t.Fatalf("failed to get dataset: %v", err)
+	}
+	if err := ctx.DB.Unscoped().Where("name = ?", "marketing").First(&marketing).Error; err != nil {
+		t.Fatalf("failed to get dataset: %v", err)
+	}
+	var table entity.Table
+	if err := ctx.DB.Where("name = ?", "orders").First(&table).Error; err != nil {
+		t.Fatalf("failed to get table: %v", err)
+	}
+
+	columns, err := TableStateAt(ctx.DB, table.ID, at)
+	if err != nil {
+		t.Fatalf("TableStateAt: %v", err)
+	}
+	var paths []string
+	for _, column := range columns {
+		paths = append(paths, column.Path)
+		if column.Path == "amount" && column.Type != "INT64" {
+			t.Errorf("amount type = %s, want INT64", column.Type)
+		}
+	}
+	if got := strings.Join(paths, ","); got != "amount,customer,customer.name,id" {
+		t.Errorf("columns = %s, want amount,customer,customer.name,id", got)
+	}
+
+	tables, err := DatasetStateAt(ctx.DB, sales.ID, at)
+	if err != nil {
+		t.Fatalf("DatasetStateAt: %v", err)
+	}
+	if len(tables) != 1 || tables[0].Name != "orders" || len(tables[0].Columns) != 4 {
+		t.Errorf("sales tables = %+v, want orders with 4 columns", tables)
+	}
+
+	// A deleted dataset still has its tables at a time it existed
+	tables, err = DatasetStateAt(ctx.DB, marketing.ID, at)
+	if err != nil {
+		t.Fatalf("DatasetStateAt: %v", err)
+	}
+	if len(tables) != 1 || tables[0].Name != "customers" {
+		t.Errorf("marketing tables = %+v, want customers", tables)
+	}
+
+	// Before the first sync nothing existed
+	tables, err = DatasetStateAt(ctx.DB, sales.ID, project.CreatedAt.Add(-time.Second))
+	if err != nil || len(tables) != 0 {
+		t.Errorf("DatasetStateAt = %+v, %v, want no tables", tables, err)
+	}
+}
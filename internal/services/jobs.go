@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/connectors"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// syncJobHeartbeat is how often the worker running a job renews its
+	// heartbeat.
+	syncJobHeartbeat = 30 * time.Second
+	// syncJobLease is how long a running job may go without a heartbeat
+	// before it is considered abandoned, e.g. because its worker was killed.
+	syncJobLease = 5 * time.Minute
+	// maxSyncJobAttempts is how often an abandoned job is claimed before it
+	// is failed rather than queued again.
+	maxSyncJobAttempts = 3
+)
+
+// activeSyncJobCondition matches queued and running jobs. It is spelled out
+// rather than bound, so that it matches the predicate of the partial unique
+// index idx_sync_job_active.
+const activeSyncJobCondition = "status IN ('queued', 'running')"
+
+// ErrSyncJobRunning is returned when a full sync is requested while a sync
+// that is not full is already running.
+var ErrSyncJobRunning = errors.New("a sync of the project is already running")
+
+// EnqueueSync queues a sync job for the project. If the project already has a
+// queued or running job of the same kind, that job is returned instead of
+// queueing another one. A queued job is upgraded to a full sync if one is
+// requested, a running one cannot be and ErrSyncJobRunning is returned.
+func EnqueueSync(ctx *appcontext.Context, projectID uuid.UUID, requestedBy *uuid.UUID, opts SyncOptions) (*entity.SyncJob, error) {
+	// The active job may finish between a failed insert and the lookup
+	for attempt := 0; attempt < 3; attempt++ {
+		job, err := createSyncJob(ctx.DB, projectID, requestedBy, opts)
+		if err != nil || job != nil {
+			return job, err
+		}
+
+		job, err = ActiveSyncJob(ctx.DB, projectID, opts.DryRun)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			continue
+		}
+
+		if opts.Full && !job.Full {
+			upgrade := ctx.DB.Model(&entity.SyncJob{}).Where("id = ? AND status = ?", job.ID, entity.SyncJobStatusQueued).Update("full", true)
+			if upgrade.Error != nil {
+				return nil, fmt.Errorf("failed to upgrade sync job to a full sync: %w", upgrade.Error)
+			}
+			if upgrade.RowsAffected == 0 {
+				return nil, ErrSyncJobRunning
+			}
+			job.Full = true
+		}
+
+		return job, nil
+	}
+
+	return nil, fmt.Errorf("failed to enqueue sync job: the project's active job kept changing")
+}
+
+// ActiveSyncJob returns the project's queued or running sync job, or nil if
+// there is none. Dry runs and real syncs are looked up separately.
+func ActiveSyncJob(db *gorm.DB, projectID uuid.UUID, dryRun bool) (*entity.SyncJob, error) {
+	var job entity.SyncJob
+	err := db.Where("project_id = ? AND dry_run = ? AND status IN ?", projectID, dryRun, []string{entity.SyncJobStatusQueued, entity.SyncJobStatusRunning}).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for active sync jobs: %w", err)
+	}
+
+	return &job, nil
+}
+
+// createSyncJob queues a job, unless the project already has a queued or
+// running job of the same kind, in which case it returns nil. The unique
+// index on active jobs makes the check atomic.
+func createSyncJob(db *gorm.DB, projectID uuid.UUID, requestedBy *uuid.UUID, opts SyncOptions) (*entity.SyncJob, error) {
+	job := entity.SyncJob{
+		ProjectID:   projectID,
+		Status:      entity.SyncJobStatusQueued,
+		Trigger:     opts.Trigger,
+		Full:        opts.Full,
+		DryRun:      opts.DryRun,
+		RequestedBy: requestedBy,
+		ScheduleID:  opts.ScheduleID,
+	}
+	result := db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "project_id"}, {Name: "dry_run"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: activeSyncJobCondition}}},
+		DoNothing:   true,
+	}).Create(&job)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to create sync job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &job, nil
+}
+
+// ClaimSyncJob marks the oldest queued job as running and returns it. Rows
+// locked by other workers are skipped, so any number of workers can poll the
+// queue concurrently. Abandoned jobs are queued again first. It returns nil if
+// there is nothing to do.
+func ClaimSyncJob(ctx *appcontext.Context) (*entity.SyncJob, error) {
+	if err := reclaimSyncJobs(ctx); err != nil {
+		return nil, err
+	}
+
+	var job entity.SyncJob
+
+	err := ctx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", entity.SyncJobStatusQueued).
+			Order("created_at").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = entity.SyncJobStatusRunning
+		job.StartedAt = &now
+		job.HeartbeatAt = &now
+		job.Attempts++
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":       job.Status,
+			"started_at":   job.StartedAt,
+			"heartbeat_at": job.HeartbeatAt,
+			"attempts":     job.Attempts,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim sync job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// reclaimSyncJobs fails the syncs of running jobs whose heartbeat expired and
+// queues the jobs again, or fails them after maxSyncJobAttempts.
+func reclaimSyncJobs(ctx *appcontext.Context) error {
+	return ctx.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var jobs []entity.SyncJob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND COALESCE(heartbeat_at, started_at) < ?", entity.SyncJobStatusRunning, now.Add(-syncJobLease)).
+			Find(&jobs).Error; err != nil {
+			return fmt.Errorf("failed to fetch abandoned sync jobs: %w", err)
+		}
+
+		for _, job := range jobs {
+			ctx.Logger.Warn("Reclaiming abandoned sync job", zap.String("job_id", job.ID.String()), zap.Int("attempts", job.Attempts))
+
+			if job.SyncID != nil {
+				var sync entity.Sync
+				err := tx.Where("id = ? AND status = ?", job.SyncID, entity.SyncStatusRunning).First(&sync).Error
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("failed to get sync of abandoned job: %w", err)
+				}
+				if err == nil {
+					if err := FinishSync(tx, &sync, nil, errors.New("the sync was interrupted")); err != nil {
+						return err
+					}
+					if err := EnqueueWebhookEvent(tx, job.ProjectID, entity.WebhookEventSyncFailed, map[string]interface{}{"sync": sync}); err != nil {
+						return err
+					}
+				}
+			}
+
+			updates := map[string]interface{}{
+				"status":       entity.SyncJobStatusQueued,
+				"sync_id":      nil,
+				"started_at":   nil,
+				"heartbeat_at": nil,
+			}
+			if job.Attempts >= maxSyncJobAttempts {
+				updates = map[string]interface{}{
+					"status":      entity.SyncJobStatusFailed,
+					"error":       fmt.Sprintf("the sync was interrupted %d times", job.Attempts),
+					"finished_at": now,
+				}
+			}
+			if err := tx.Model(&job).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to reclaim sync job: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// RunSyncJob syncs the job's project and stores the outcome on the job. The
+// sync run is recorded before the source is read, so failed syncs show up in
+// the project's sync history as well.
+func RunSyncJob(ctx *appcontext.Context, job *entity.SyncJob) error {
+	stop, stopHeartbeat := heartbeat(ctx, job)
+	defer stopHeartbeat()
+
+	updates := map[string]interface{}{}
+
+	opts := SyncOptions{
+		Trigger:     job.Trigger,
+		Full:        job.Full,
+		DryRun:      job.DryRun,
+		RequestedBy: job.RequestedBy,
+		ScheduleID:  job.ScheduleID,
+	}
+
+	// A successful sync is finished in the same transaction as its changes
+	var result *SyncResult
+	sync, err := startSyncJob(ctx, job, opts)
+	if err == nil {
+		result, err = runSync(ctx, stop, job.ProjectID, sync, opts)
+	}
+	if sync != nil && err != nil {
+		if err := FinishSync(ctx.DB, sync, result, err); err != nil {
+			ctx.Logger.Error("Failed to record sync outcome", zap.Error(err), zap.String("sync_id", sync.ID.String()))
+		}
+		if err := EnqueueWebhookEvent(ctx.DB, job.ProjectID, entity.WebhookEventSyncFailed, map[string]interface{}{"sync": sync}); err != nil {
+			ctx.Logger.Error("Failed to enqueue webhooks", zap.Error(err), zap.String("sync_id", sync.ID.String()))
+		}
+	}
+
+	if err != nil {
+		ctx.Logger.Error("Sync job failed", zap.Error(err), zap.String("job_id", job.ID.String()))
+		updates["status"] = entity.SyncJobStatusFailed
+		updates["error"] = err.Error()
+	} else {
+		updates["status"] = entity.SyncJobStatusSucceeded
+		if result.DryRun {
+			resultBytes, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("failed to marshal dry run result: %w", err)
+			}
+			updates["result"] = string(resultBytes)
+		}
+	}
+	updates["finished_at"] = time.Now()
+
+	// A job that was reclaimed in the meantime belongs to another worker
+	if err := ctx.DB.Model(job).Where("status = ? AND attempts = ?", entity.SyncJobStatusRunning, job.Attempts).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update sync job: %w", err)
+	}
+
+	return nil
+}
+
+// startSyncJob records the sync run of a job that is not a dry run. The sync
+// is returned even if linking it to the job failed, so that it can be failed.
+func startSyncJob(ctx *appcontext.Context, job *entity.SyncJob, opts SyncOptions) (*entity.Sync, error) {
+	if job.DryRun {
+		return nil, nil
+	}
+
+	sync, err := StartSync(ctx.DB, job.ProjectID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.DB.Model(job).Update("sync_id", sync.ID).Error; err != nil {
+		return sync, fmt.Errorf("failed to update sync job: %w", err)
+	}
+
+	return sync, nil
+}
+
+// heartbeat renews the job's heartbeat every syncJobHeartbeat until the
+// returned function is called. The returned context is done once the job was
+// reclaimed, so that the worker stops syncing a job it no longer owns.
+func heartbeat(ctx *appcontext.Context, job *entity.SyncJob) (context.Context, func()) {
+	done := make(chan struct{})
+	stop, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(syncJobHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				result := ctx.DB.Model(&entity.SyncJob{}).Where("id = ? AND status = ? AND attempts = ?", job.ID, entity.SyncJobStatusRunning, job.Attempts).Update("heartbeat_at", time.Now())
+				if err := result.Error; err != nil {
+					ctx.Logger.Error("Failed to renew sync job heartbeat", zap.Error(err), zap.String("job_id", job.ID.String()))
+					continue
+				}
+				if result.RowsAffected == 0 {
+					ctx.Logger.Warn("Sync job was reclaimed, stopping", zap.String("job_id", job.ID.String()))
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return stop, func() {
+		close(done)
+		cancel()
+	}
+}
+
+func runSync(ctx *appcontext.Context, stop context.Context, projectID uuid.UUID, sync *entity.Sync, opts SyncOptions) (result *SyncResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sync panicked: %v", r)
+		}
+	}()
+
+	conn, err := connectors.Open(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return SyncProject(ctx, stop, projectID, sync, conn, opts)
+}
+
+// StartSync records a running sync for the project.
+func StartSync(db *gorm.DB, projectID uuid.UUID, opts SyncOptions) (*entity.Sync, error) {
+	now := time.Now()
+	sync := entity.Sync{
+		ID:          uuid.New(),
+		ProjectID:   &projectID,
+		Trigger:     opts.Trigger,
+		Status:      entity.SyncStatusRunning,
+		TriggeredBy: opts.RequestedBy,
+		ScheduleID:  opts.ScheduleID,
+		StartedAt:   &now,
+	}
+	if err := db.Create(&sync).Error; err != nil {
+		return nil, fmt.Errorf("failed to create sync: %w", err)
+	}
+
+	return &sync, nil
+}
+
+// FinishSync stores the outcome of a sync started with StartSync. result may
+// be nil if the sync failed.
+func FinishSync(db *gorm.DB, sync *entity.Sync, result *SyncResult, syncErr error) error {
+	now := time.Now()
+	sync.FinishedAt = &now
+	sync.Status = entity.SyncStatusSucceeded
+	if syncErr != nil {
+		sync.Status = entity.SyncStatusFailed
+		sync.Error = syncErr.Error()
+	}
+	if result != nil {
+		sync.Counts = result.Counts
+		if len(result.Warnings) > 0 {
+			sync.Status = entity.SyncStatusPartial
+			for i := range result.Warnings {
+				result.Warnings[i].SyncID = &sync.ID
+			}
+			if err := db.Create(&result.Warnings).Error; err != nil {
+				return fmt.Errorf("failed to create sync warnings: %w", err)
+			}
+		}
+	}
+
+	if err := db.Model(sync).Select("status", "error", "finished_at", "datasets_scanned", "tables_scanned", "columns_scanned", "datasets_changed", "tables_changed", "columns_changed", "breaking_changes", "policy_violations").Updates(sync).Error; err != nil {
+		return fmt.Errorf("failed to update sync: %w", err)
+	}
+
+	return nil
+}
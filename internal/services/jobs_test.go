@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestEnqueueSyncDeduplicates(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	first, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil {
+		t.Fatalf("EnqueueSync: %v", err)
+	}
+	second, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil {
+		t.Fatalf("EnqueueSync: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("second job = %s, want the queued job %s", second.ID, first.ID)
+	}
+
+	dryRun, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual, DryRun: true})
+	if err != nil {
+		t.Fatalf("EnqueueSync: %v", err)
+	}
+	if dryRun.ID == first.ID {
+		t.Errorf("dry run reused the queued sync job")
+	}
+
+	// The unique index rejects a second active job even without the lookup
+	job, err := createSyncJob(ctx.DB, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil || job != nil {
+		t.Errorf("createSyncJob = %v, %v, want no job", job, err)
+	}
+
+	var jobs int64
+	ctx.DB.Model(&entity.SyncJob{}).Where("project_id = ?", project.ID).Count(&jobs)
+	if jobs != 2 {
+		t.Errorf("got %d jobs, want 2", jobs)
+	}
+}
+
+func TestEnqueueSyncFull(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	queued, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil {
+		t.Fatalf("EnqueueSync: %v", err)
+	}
+
+	full, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual, Full: true})
+	if err != nil {
+		t.Fatalf("EnqueueSync: %v", err)
+	}
+	if full.ID != queued.ID || !full.Full {
+		t.Errorf("job = %s, full %v, want the queued job %s upgraded to full", full.ID, full.Full, queued.ID)
+	}
+
+	claimed, err := ClaimSyncJob(ctx)
+	if err != nil || claimed == nil || !claimed.Full {
+		t.Fatalf("ClaimSyncJob = %+v, %v, want the full job", claimed, err)
+	}
+
+	// A full sync cannot be merged into one that is already running
+	if err := ctx.DB.Model(claimed).Update("full", false).Error; err != nil {
+		t.Fatalf("failed to update job: %v", err)
+	}
+	if _, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual, Full: true}); !errors.Is(err, ErrSyncJobRunning) {
+		t.Errorf("EnqueueSync error = %v, want ErrSyncJobRunning", err)
+	}
+}
+
+func TestClaimSyncJobReclaimsAbandonedJobs(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	if _, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual}); err != nil {
+		t.Fatalf("EnqueueSync: %v", err)
+	}
+	job, err := ClaimSyncJob(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimSyncJob = %v, %v, want a job", job, err)
+	}
+	sync, err := startSyncJob(ctx, job, SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil {
+		t.Fatalf("startSyncJob: %v", err)
+	}
+
+	// A job with a live heartbeat is left alone
+	if again, err := ClaimSyncJob(ctx); err != nil || again != nil {
+		t.Fatalf("ClaimSyncJob = %v, %v, want nothing to claim", again, err)
+	}
+
+	expired := time.Now().Add(-2 * syncJobLease)
+	if err := ctx.DB.Model(job).Update("heartbeat_at", expired).Error; err != nil {
+		t.Fatalf("failed to expire heartbeat: %v", err)
+	}
+
+	reclaimed, err := ClaimSyncJob(ctx)
+	if err != nil || reclaimed == nil || reclaimed.ID != job.ID {
+		t.Fatalf("ClaimSyncJob = %v, %v, want the abandoned job", reclaimed, err)
+	}
+	if reclaimed.Attempts != 2 || reclaimed.SyncID != nil {
+		t.Errorf("reclaimed job has %d attempts and sync %v, want 2 attempts and no sync", reclaimed.Attempts, reclaimed.SyncID)
+	}
+
+	var stored entity.Sync
+	if err := ctx.DB.Where("id = ?", sync.ID).First(&stored).Error; err != nil {
+		t.Fatalf("failed to get sync: %v", err)
+	}
+	if stored.Status != entity.SyncStatusFailed || stored.FinishedAt == nil {
+		t.Errorf("abandoned sync status = %s, want failed and finished", stored.Status)
+	}
+
+	// After maxSyncJobAttempts the job is failed rather than queued again
+	if err := ctx.DB.Model(reclaimed).Updates(map[string]interface{}{"attempts": maxSyncJobAttempts, "heartbeat_at": expired}).Error; err != nil {
+		t.Fatalf("failed to expire heartbeat: %v", err)
+	}
+	if again, err := ClaimSyncJob(ctx); err != nil || again != nil {
+		t.Fatalf("ClaimSyncJob = %v, %v, want nothing to claim", again, err)
+	}
+
+	var failed entity.SyncJob
+	if err := ctx.DB.Where("id = ?", job.ID).First(&failed).Error; err != nil {
+		t.Fatalf("failed to get job: %v", err)
+	}
+	if failed.Status != entity.SyncJobStatusFailed || failed.Error == "" {
+		t.Errorf("job status = %s with error %q, want failed with an error", failed.Status, failed.Error)
+	}
+}
+
+func TestRunSyncJobFailure(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	if err := ctx.DB.Model(&project).Update("source_type", "unknown").Error; err != nil {
+		t.Fatalf("failed to update project: %v", err)
+	}
+
+	if _, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual}); err != nil {
+		t.Fatalf("EnqueueSync: %v", err)
+	}
+	job, err := ClaimSyncJob(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimSyncJob = %v, %v, want a job", job, err)
+	}
+
+	if err := RunSyncJob(ctx, job); err != nil {
+		t.Fatalf("RunSyncJob: %v", err)
+	}
+
+	var stored entity.SyncJob
+	if err := ctx.DB.Where("id = ?", job.ID).First(&stored).Error; err != nil {
+		t.Fatalf("failed to get job: %v", err)
+	}
+	if stored.Status != entity.SyncJobStatusFailed || stored.SyncID == nil {
+		t.Fatalf("job status = %s with sync %v, want failed with a sync", stored.Status, stored.SyncID)
+	}
+
+	var sync entity.Sync
+	if err := ctx.DB.Where("id = ?", stored.SyncID).First(&sync).Error; err != nil {
+		t.Fatalf("failed to get sync: %v", err)
+	}
+	if sync.Status != entity.SyncStatusFailed || sync.Error == "" {
+		t.Errorf("sync status = %s with error %q, want failed with an error", sync.Status, sync.Error)
+	}
+
+	// The project can be synced again
+	if _, err := EnqueueSync(ctx, project.ID, nil, SyncOptions{Trigger: entity.SyncTriggerManual}); err != nil {
+		t.Errorf("EnqueueSync: %v", err)
+	}
+}
@@ -1,17 +1,156 @@
 package services
 
 import (
+	"bytes"
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
 	"os"
+	"strings"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
 )
 
+const (
+	mailFromName    = "Katalog"
+	mailFromAddress = "no-reply@katalog.so"
+)
+
+// MailMessage is an email with a plain text and an HTML body.
+type MailMessage struct {
+	ToName    string
+	ToAddress string
+	Subject   string
+	PlainText string
+	HTML      string
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	Send(message MailMessage) error
+}
+
+// NewMailer returns the mailer selected by MAIL_DRIVER: "smtp" sends through
+// the server at SMTP_ADDR, such as a local mail catcher, anything else
+// through SendGrid.
+func NewMailer() Mailer {
+	if os.Getenv("MAIL_DRIVER") == "smtp" {
+		addr := os.Getenv("SMTP_ADDR")
+		if addr == "" {
+			addr = "localhost:1025"
+		}
+		return &SMTPMailer{
+			Addr:     addr,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	}
+
+	return &SendGridMailer{APIKey: os.Getenv("SENDGRID_API_KEY")}
+}
+
+type SendGridMailer struct {
+	APIKey string
+}
+
+// Send returns an error if SendGrid rejects the email, not only if it cannot
+// be reached, so that digests are retried and invitations are not reported as
+// sent when they were not.
+func (m *SendGridMailer) Send(message MailMessage) error {
+	from := mail.NewEmail(mailFromName, mailFromAddress)
+	to := mail.NewEmail(message.ToName, message.ToAddress)
+
+	email := mail.NewSingleEmail(from, message.Subject, to, message.PlainText, message.HTML)
+	response, err := sendgrid.NewSendClient(m.APIKey).Send(email)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("sendgrid responded with status %d: %s", response.StatusCode, response.Body)
+	}
+	return nil
+}
+
+// SMTPMailer sends emails through an SMTP server. Username and Password are
+// optional, mail catchers usually accept mail without authentication.
+type SMTPMailer struct {
+	Addr     string
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(message MailMessage) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host := strings.Split(m.Addr, ":")[0]
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	body, err := mimeMessage(message)
+	if err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(m.Addr, auth, mailFromAddress, []string{message.ToAddress}, body); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// mimeMessage encodes the message as multipart/alternative, with the plain
+// text body first.
+func mimeMessage(message MailMessage) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", message.PlainText},
+		{"text/html; charset=UTF-8", message.HTML},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email part: %w", err)
+		}
+		encoder := quotedprintable.NewWriter(partWriter)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to write email part: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write email part: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write email: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s <%s>\r\n", mailFromName, mailFromAddress)
+	fmt.Fprintf(&msg, "To: %s\r\n", message.ToAddress)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+// SendInvitationEmail emails an invitation through the mailer selected by
+// NewMailer. An invitation rejected by SendGrid is an error, the invite
+// endpoint used to report it as sent.
 func SendInvitationEmail(toEmail, inviterName, loginURL string) error {
-	from := mail.NewEmail("Katalog", "no-reply@katalog.so")
 	subject := fmt.Sprintf("%s has invited you to join their team on Katalog!", inviterName)
-	to := mail.NewEmail("New User", toEmail)
 
 	htmlContent := fmt.Sprintf(`
         <div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; background-color: #f4f4f4; text-align: center;">
@@ -33,11 +172,11 @@ func SendInvitationEmail(toEmail, inviterName, loginURL string) error {
 
 	plainTextContent := fmt.Sprintf("Hello, you've been invited to join Katalog by %s. Click the link below to get started: %s", inviterName, loginURL)
 
-	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
-	client := sendgrid.NewSendClient(os.Getenv("SENDGRID_API_KEY"))
-	_, err := client.Send(message)
-	if err != nil {
-		return err
-	}
-	return nil
+	return NewMailer().Send(MailMessage{
+		ToName:    "New User",
+		ToAddress: toEmail,
+		Subject:   subject,
+		PlainText: plainTextContent,
+		HTML:      htmlContent,
+	})
 }
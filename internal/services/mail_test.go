@@ -0,0 +1,151 @@
+package services
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpCatcher is an SMTP server that accepts every email and keeps it, like
+// the mail catchers SMTPMailer is used with in development.
+type smtpCatcher struct {
+	listener net.Listener
+	from     []string
+	to       []string
+	data     chan string
+}
+
+func newSMTPCatcher(t *testing.T) *smtpCatcher {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	catcher := &smtpCatcher{listener: listener, data: make(chan string, 1)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			catcher.serve(conn)
+		}
+	}()
+
+	return catcher
+}
+
+func (c *smtpCatcher) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+
+	text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "MAIL":
+			c.from = append(c.from, line)
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			c.to = append(c.to, line)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 Go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			c.data <- string(data)
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	catcher := newSMTPCatcher(t)
+	mailer := &SMTPMailer{Addr: catcher.listener.Addr().String()}
+
+	err := mailer.Send(MailMessage{
+		ToName:    "Ada",
+		ToAddress: "ada@example.com",
+		Subject:   "Daily digest für sales",
+		PlainText: "New tables (1)\n  - sales.orders\n",
+		HTML:      "<h2>New tables (1)</h2><ul><li>sales.orders</li></ul>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	data := <-catcher.data
+
+	if len(catcher.from) != 1 || !strings.Contains(catcher.from[0], mailFromAddress) {
+		t.Errorf("MAIL commands = %v, want one from %s", catcher.from, mailFromAddress)
+	}
+	if len(catcher.to) != 1 || !strings.Contains(catcher.to[0], "ada@example.com") {
+		t.Errorf("RCPT commands = %v, want one to ada@example.com", catcher.to)
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to parse email: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || subject != "Daily digest für sales" {
+		t.Errorf("subject = %q, %v, want the subject sent", subject, err)
+	}
+	if to := message.Header.Get("To"); to != "ada@example.com" {
+		t.Errorf("to = %q, want ada@example.com", to)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q, %v, want multipart/alternative", mediaType, err)
+	}
+
+	var parts []string
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		// NextPart decodes quoted-printable parts
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		parts = append(parts, part.Header.Get("Content-Type")+": "+string(content))
+	}
+
+	want := []string{
+		"text/plain; charset=UTF-8: New tables (1)\n  - sales.orders\n",
+		"text/html; charset=UTF-8: <h2>New tables (1)</h2><ul><li>sales.orders</li></ul>",
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("parts = %q, want %q", parts, want)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d = %q, want %q", i, parts[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrColumnRenameResolved = errors.New("column rename has already been resolved")
+
+// recordColumnRenames stores a suggestion for every rename in the changelog of
+// a sync and carries the curated description of the old column over to the
+// new one, unless the new column already has one. It returns the columns that
+// received a description, to be reindexed once the sync is committed.
+func recordColumnRenames(tx *gorm.DB, projectID, syncID uuid.UUID, changelogs []entity.Changelog) ([]entity.Column, error) {
+	var carried []entity.Column
+	for _, changelog := range changelogs {
+		if changelog.ChangeType != "rename" || changelog.EntityType != "column" || changelog.ParentID == nil {
+			continue
+		}
+
+		var oldPath string
+		if err := json.Unmarshal([]byte(changelog.OldValue), &oldPath); err != nil {
+			return nil, fmt.Errorf("failed to decode renamed column path: %w", err)
+		}
+
+		var oldColumn entity.Column
+		if err := tx.Unscoped().
+			Where("table_id = ? AND path = ? AND deleted_at IS NOT NULL", changelog.ParentID, oldPath).
+			Order("deleted_at DESC").
+			First(&oldColumn).Error; err != nil {
+			return nil, fmt.Errorf("failed to get renamed column: %w", err)
+		}
+
+		var newColumn entity.Column
+		if err := tx.Where("id = ?", changelog.EntityID).First(&newColumn).Error; err != nil {
+			return nil, fmt.Errorf("failed to get renamed column: %w", err)
+		}
+
+		rename := entity.ColumnRename{
+			ProjectID:   projectID,
+			SyncID:      syncID,
+			TableID:     newColumn.TableID,
+			OldColumnID: oldColumn.ID,
+			NewColumnID: newColumn.ID,
+			OldPath:     oldColumn.Path,
+			NewPath:     newColumn.Path,
+			Status:      entity.ColumnRenameStatusSuggested,
+		}
+
+		if newColumn.CuratedDescription == "" && oldColumn.CuratedDescription != "" {
+			rename.CarriedDescription = oldColumn.CuratedDescription
+			if err := tx.Model(&newColumn).Update("curated_description", oldColumn.CuratedDescription).Error; err != nil {
+				return nil, fmt.Errorf("failed to carry over curated description: %w", err)
+			}
+			newColumn.CuratedDescription = oldColumn.CuratedDescription
+			carried = append(carried, newColumn)
+		}
+
+		if err := tx.Create(&rename).Error; err != nil {
+			return nil, fmt.Errorf("failed to create column rename: %w", err)
+		}
+	}
+
+	return carried, nil
+}
+
+// ResolveColumnRename confirms or rejects a suggested rename. Confirming it
+// moves the watches on the old column to the new one. Rejecting it removes
+// the carried over description from the new column and logs the change as
+// the removal of the old column and the addition of the new one. Of several
+// concurrent requests, only one resolves the rename.
+func ResolveColumnRename(ctx *appcontext.Context, userID, renameID uuid.UUID, confirm bool) (*entity.ColumnRename, error) {
+	var rename entity.ColumnRename
+	if err := ctx.DB.Where("id = ?", renameID).First(&rename).Error; err != nil {
+		return nil, fmt.Errorf("failed to get column rename: %w", err)
+	}
+	if rename.Status != entity.ColumnRenameStatusSuggested {
+		return nil, ErrColumnRenameResolved
+	}
+
+	now := time.Now()
+	rename.ResolvedBy = &userID
+	rename.ResolvedAt = &now
+	rename.Status = entity.ColumnRenameStatusConfirmed
+	if !confirm {
+		rename.Status = entity.ColumnRenameStatusRejected
+	}
+
+	var newColumn entity.Column
+	err := ctx.DB.Transaction(func(tx *gorm.DB) error {
+		// The status is checked again by the update, a rename resolved since
+		// it was read is left alone
+		result := tx.Model(&entity.ColumnRename{}).
+			Where("id = ? AND status = ?", rename.ID, entity.ColumnRenameStatusSuggested).
+			Updates(map[string]interface{}{
+				"status":      rename.Status,
+				"resolved_by": rename.ResolvedBy,
+				"resolved_at": rename.ResolvedAt,
+			})
+		if err := result.Error; err != nil {
+			return fmt.Errorf("failed to update column rename: %w", err)
+		}
+		if result.RowsAffected == 0 {
+			return ErrColumnRenameResolved
+		}
+
+		if confirm {
+			return moveWatches(tx, &rename)
+		}
+
+		if err := tx.Where("id = ?", rename.NewColumnID).First(&newColumn).Error; err != nil {
+			return fmt.Errorf("failed to get renamed column: %w", err)
+		}
+
+		// The description is only removed if nobody edited it since
+		if rename.CarriedDescription != "" && newColumn.CuratedDescription == rename.CarriedDescription {
+			if err := tx.Model(&newColumn).Update("curated_description", "").Error; err != nil {
+				return fmt.Errorf("failed to remove carried over description: %w", err)
+			}
+		}
+
+		var changelog entity.Changelog
+		if err := tx.Where("sync_id = ? AND entity_id = ? AND change_type = ?", rename.SyncID, rename.NewColumnID, "rename").First(&changelog).Error; err != nil {
+			return fmt.Errorf("failed to get rename changelog: %w", err)
+		}
+
+		removal := changelog
+		removal.ID = uuid.New()
+		removal.Model = gorm.Model{}
+		removal.ChangeType = "delete"
+		removal.EntityID = rename.OldColumnID
+		removal.EntityName = rename.OldPath
+		removal.FieldName = ""
+		removal.OldValue = ""
+		removal.NewValue = ""
+		removal.Severity = entity.ChangeSeverityBreaking
+		if changelog.PolicyViolation != "" && !utils.ViolatesPolicy(&removal, changelog.PolicyViolation) {
+			removal.PolicyViolation = ""
+		}
+		if err := tx.Create(&removal).Error; err != nil {
+			return fmt.Errorf("failed to create changelog: %w", err)
+		}
+
+		if err := tx.Model(&changelog).Updates(map[string]interface{}{
+			"change_type":      "insert",
+			"field_name":       "",
+			"old_value":        "",
+			"new_value":        "",
+			"severity":         entity.ChangeSeveritySafe,
+			"policy_violation": "",
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update rename changelog: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !confirm {
+		if err := ctx.DB.Where("id = ?", rename.NewColumnID).First(&newColumn).Error; err == nil {
+			reindexColumn(ctx, &newColumn)
+		}
+	}
+
+	return &rename, nil
+}
+
+func reindexColumn(ctx *appcontext.Context, column *entity.Column) {
+	columnDoc, err := utils.ColumnToDocument(ctx.DB, column)
+	if err != nil {
+		ctx.Logger.Error("Failed to create column document", zap.Error(err), zap.String("column_id", column.ID.String()))
+		return
+	}
+	reindex(ctx, columnDoc)
+}
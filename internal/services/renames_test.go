@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestResolveColumnRenameOnce(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	conn.Table("sales", "orders").Columns[1].Name = "total"
+	run, _ := mustSync(t, ctx, project.ID, conn)
+
+	var rename entity.ColumnRename
+	if err := ctx.DB.Where("sync_id = ?", run.ID).First(&rename).Error; err != nil {
+		t.Fatalf("failed to get rename: %v", err)
+	}
+
+	// A confirmation and a rejection race, only one of them resolves the rename
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, confirm := range []bool{true, false} {
+		wg.Add(1)
+		go func(i int, confirm bool) {
+			defer wg.Done()
+			_, errs[i] = ResolveColumnRename(ctx, user.ID, rename.ID, confirm)
+		}(i, confirm)
+	}
+	wg.Wait()
+
+	resolved := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			resolved++
+		case !errors.Is(err, ErrColumnRenameResolved):
+			t.Fatalf("ResolveColumnRename: %v", err)
+		}
+	}
+	if resolved != 1 {
+		t.Fatalf("rename was resolved %d times, want once", resolved)
+	}
+
+	if _, err := ResolveColumnRename(ctx, user.ID, rename.ID, true); !errors.Is(err, ErrColumnRenameResolved) {
+		t.Errorf("ResolveColumnRename of a resolved rename = %v, want ErrColumnRenameResolved", err)
+	}
+
+	var removals int64
+	ctx.DB.Model(&entity.Changelog{}).Where("sync_id = ? AND change_type = ?", run.ID, "delete").Count(&removals)
+	if removals > 1 {
+		t.Errorf("got %d removals of the old column, want at most one", removals)
+	}
+}
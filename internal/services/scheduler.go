@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NextScheduledRun returns the first time after the given time that matches
+// the schedule's cron expression, evaluated in the schedule's timezone.
+func NextScheduledRun(cronExpression, timezone string, after time.Time) (time.Time, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	schedule, err := cron.ParseStandard(cronExpression)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	return schedule.Next(after.In(location)), nil
+}
+
+// RunScheduler enqueues syncs for due schedules every interval until stop is
+// done. Due schedules are locked while they are processed, so several
+// processes can run the scheduler without queueing a run twice.
+func RunScheduler(ctx *appcontext.Context, stop context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := enqueueDueSyncs(ctx); err != nil {
+			ctx.Logger.Error("Failed to enqueue scheduled syncs", zap.Error(err))
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func enqueueDueSyncs(ctx *appcontext.Context) error {
+	return ctx.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var schedules []entity.SyncSchedule
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("enabled = ? AND next_run_at <= ?", true, now).
+			Find(&schedules).Error; err != nil {
+			return fmt.Errorf("failed to fetch due schedules: %w", err)
+		}
+
+		for _, schedule := range schedules {
+			job, err := ActiveSyncJob(tx, schedule.ProjectID, false)
+			if err != nil {
+				return err
+			}
+
+			if job != nil {
+				ctx.Logger.Info("Skipping scheduled sync, a sync is already in progress", zap.String("project_id", schedule.ProjectID.String()), zap.String("job_id", job.ID.String()))
+			} else if _, err := createSyncJob(tx, schedule.ProjectID, nil, SyncOptions{Trigger: entity.SyncTriggerScheduled, ScheduleID: &schedule.ID}); err != nil {
+				return err
+			}
+
+			updates := map[string]interface{}{"last_run_at": now}
+			nextRunAt, err := NextScheduledRun(schedule.CronExpression, schedule.Timezone, now)
+			if err != nil {
+				ctx.Logger.Error("Disabling invalid sync schedule", zap.Error(err), zap.String("schedule_id", schedule.ID.String()))
+				updates["enabled"] = false
+			} else {
+				updates["next_run_at"] = nextRunAt
+			}
+
+			if err := tx.Model(&schedule).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update schedule: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
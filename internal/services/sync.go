@@ -0,0 +1,601 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/connectors"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SyncOptions struct {
+	// Trigger records whether the sync was started manually or by a schedule.
+	Trigger string
+	// Full re-reads every table, even those unchanged since the last sync.
+	Full bool
+	// DryRun crawls the source and computes the changelog, then rolls the
+	// transaction back without touching the search index.
+	DryRun bool
+	// RequestedBy and ScheduleID record who or what started the sync.
+	RequestedBy *uuid.UUID
+	ScheduleID  *uuid.UUID
+}
+
+type SyncResult struct {
+	Sync       *entity.Sync              `json:"sync,omitempty"`
+	DryRun     bool                      `json:"dry_run"`
+	Counts     entity.SyncCounts         `json:"counts"`
+	Warnings   []entity.SyncWarning      `json:"warnings"`
+	Changelogs []entity.Changelog        `json:"changelogs"`
+	Totals     map[string]map[string]int `json:"totals"`
+}
+
+func newSyncResult(sync *entity.Sync, dryRun bool, counts entity.SyncCounts, warnings []entity.SyncWarning, changelogs []entity.Changelog) *SyncResult {
+	totals := map[string]map[string]int{
+		"dataset": {},
+		"table":   {},
+		"column":  {},
+	}
+	changed := map[string]map[uuid.UUID]bool{}
+	for _, changelog := range changelogs {
+		if totals[changelog.EntityType] == nil {
+			totals[changelog.EntityType] = map[string]int{}
+		}
+		if changed[changelog.EntityType] == nil {
+			changed[changelog.EntityType] = map[uuid.UUID]bool{}
+		}
+		totals[changelog.EntityType][changelog.ChangeType]++
+		changed[changelog.EntityType][changelog.EntityID] = true
+
+		if changelog.Severity == entity.ChangeSeverityBreaking {
+			counts.BreakingChanges++
+		}
+		if changelog.PolicyViolation != "" {
+			counts.PolicyViolations++
+		}
+	}
+
+	counts.DatasetsChanged = len(changed["dataset"])
+	counts.TablesChanged = len(changed["table"])
+	counts.ColumnsChanged = len(changed["column"])
+
+	return &SyncResult{
+		Sync:       sync,
+		DryRun:     dryRun,
+		Counts:     counts,
+		Warnings:   warnings,
+		Changelogs: changelogs,
+		Totals:     totals,
+	}
+}
+
+// SyncProject crawls the source behind conn, upserts datasets, tables and
+// columns for the project, removes entities that no longer exist, updates
+// the search index and records the changelog under sync. In a dry run sync
+// is nil and the changes are computed and returned, but nothing is persisted.
+// The source is no longer read once stop is done.
+func SyncProject(ctx *appcontext.Context, stop context.Context, projectID uuid.UUID, sync *entity.Sync, conn connectors.Connector, opts SyncOptions) (*SyncResult, error) {
+	var project entity.Project
+	if err := ctx.DB.Where("id = ?", projectID).First(&project).Error; err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	oldState, err := utils.FetchCurrentState(ctx.DB, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch old state for changelog: %w", err)
+	}
+
+	knownDatasets := make(map[string]entity.Dataset)
+	knownTables := make(map[string]map[string]entity.Table)
+	for _, ds := range oldState {
+		knownDatasets[ds.Name] = ds
+		knownTables[ds.Name] = make(map[string]entity.Table)
+		for _, tbl := range ds.Tables {
+			knownTables[ds.Name][tbl.Name] = tbl
+		}
+	}
+
+	filters, err := LoadSyncFilters(ctx.DB, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := LoadCompatibilityPolicies(ctx.DB, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entities that match the filters are not crawled and get removed like
+	// entities dropped at the source, but the changelog records them as excluded
+	excluded := make(map[uuid.UUID]bool)
+	for _, ds := range oldState {
+		if !filters.DatasetAllowed(ds.Name) {
+			excluded[ds.ID] = true
+			continue
+		}
+		for _, tbl := range ds.Tables {
+			if !filters.TableAllowed(ds.Name, tbl.Name) {
+				excluded[tbl.ID] = true
+			}
+		}
+	}
+
+	// The source is crawled before the transaction is opened, so that slow
+	// metadata calls do not hold database locks.
+	datasets, err := crawl(stop, conn, &project, knownTables, filters, opts.Full)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows created before this point that reappear in the new state were
+	// soft-deleted and have been restored by the upserts below
+	startedAt := time.Now()
+
+	tx := ctx.DB.Begin()
+	if err := tx.Error; err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := tx.Model(&entity.Dataset{}).Where("project_id = ?", projectID).Update("to_delete", true).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to mark datasets potentially for delete: %w", err)
+	}
+	if err := tx.Model(&entity.Table{}).Where("dataset_id IN (SELECT id FROM datasets WHERE project_id = ?)", projectID).Update("to_delete", true).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to mark tables potentially for delete: %w", err)
+	}
+	if err := tx.Model(&entity.Column{}).Where("table_id IN (SELECT id FROM tables WHERE dataset_id IN (SELECT id FROM datasets WHERE project_id = ?))", projectID).Update("to_delete", true).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to mark columns potentially for delete: %w", err)
+	}
+
+	var documentsToIndex []map[string]interface{}
+	var warnings []entity.SyncWarning
+	counts := entity.SyncCounts{}
+
+	for _, crawled := range datasets {
+		dsMeta := crawled.metadata
+
+		// Datasets whose tables could not be listed keep their previous state
+		if crawled.err != nil {
+			ctx.Logger.Warn("Failed to list tables, keeping the dataset's previous state", zap.Error(crawled.err), zap.String("dataset", dsMeta.Name))
+			warnings = append(warnings, entity.SyncWarning{EntityType: "dataset", EntityName: dsMeta.Name, Message: crawled.err.Error()})
+			if existing, exists := knownDatasets[dsMeta.Name]; exists {
+				if err := keepDataset(tx, existing.ID); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
+			continue
+		}
+		if crawled.modifiedTimesErr != nil {
+			ctx.Logger.Warn("Failed to list last modified times, describing every table of the dataset", zap.Error(crawled.modifiedTimesErr), zap.String("dataset", dsMeta.Name))
+			warnings = append(warnings, entity.SyncWarning{EntityType: "dataset", EntityName: dsMeta.Name, Message: fmt.Sprintf("failed to list last modified times, described every table: %v", crawled.modifiedTimesErr)})
+		}
+		counts.DatasetsScanned++
+
+		dataset := entity.Dataset{
+			Name:        dsMeta.Name,
+			ProjectID:   projectID,
+			Description: dsMeta.Description,
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "name"}, {Name: "project_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"description": dsMeta.Description,
+				"updated_at":  time.Now(),
+				"to_delete":   false,
+				"deleted_at":  nil,
+			}),
+		}).Create(&dataset).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to create or update dataset: %w", err)
+		}
+
+		datasetDoc := utils.DatasetToDocument(&dataset)
+		documentsToIndex = append(documentsToIndex, datasetDoc)
+
+		for _, crawledTbl := range crawled.tables {
+			existing, exists := knownTables[dsMeta.Name][crawledTbl.name]
+
+			if crawledTbl.err != nil {
+				ctx.Logger.Warn("Failed to describe table, keeping its previous state", zap.Error(crawledTbl.err), zap.String("dataset", dsMeta.Name), zap.String("table", crawledTbl.name))
+				warnings = append(warnings, entity.SyncWarning{EntityType: "table", EntityName: dsMeta.Name + "." + crawledTbl.name, Message: crawledTbl.err.Error()})
+			}
+
+			// Unchanged or failed tables keep their previous state
+			if crawledTbl.metadata == nil {
+				if exists {
+					if err := keepTable(tx, existing.ID); err != nil {
+						tx.Rollback()
+						return nil, err
+					}
+				}
+				continue
+			}
+
+			counts.TablesScanned++
+			counts.ColumnsScanned += countColumns(crawledTbl.metadata.Columns)
+
+			table := tableFromMetadata(dataset.ID, crawledTbl.metadata)
+			unchanged := !opts.Full && exists && existing.SchemaFingerprint == table.SchemaFingerprint && sameTime(existing.LastModifiedTime, table.LastModifiedTime)
+
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "name"}, {Name: "dataset_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"description":        table.Description,
+					"type":               table.Type,
+					"row_count":          table.RowCount,
+					"num_bytes":          table.NumBytes,
+					"location":           table.Location,
+					"time_partitioning":  table.TimePartitioning,
+					"range_partitioning": table.RangePartitioning,
+					"clustering":         table.Clustering,
+					"labels":             table.Labels,
+					"expiration_time":    table.ExpirationTime,
+					"creation_time":      table.CreationTime,
+					"last_modified_time": table.LastModifiedTime,
+					"schema_fingerprint": table.SchemaFingerprint,
+					"updated_at":         time.Now(),
+					"to_delete":          false,
+					"deleted_at":         nil,
+				}),
+			}).Create(&table).Error; err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("failed to create or update table: %w", err)
+			}
+
+			if unchanged {
+				if err := keepTable(tx, table.ID); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+				continue
+			}
+
+			tableDoc, err := utils.TableToDocument(tx, &table)
+			if err != nil {
+				ctx.Logger.Error("Failed to create table document", zap.Error(err), zap.String("table_id", table.ID.String()))
+			} else {
+				documentsToIndex = append(documentsToIndex, tableDoc)
+			}
+
+			columnDocs, err := upsertColumns(ctx, tx, table.ID, nil, "", 0, crawledTbl.metadata.Columns)
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			documentsToIndex = append(documentsToIndex, columnDocs...)
+		}
+	}
+
+	// Handle deletions
+	var datasetsToDelete []entity.Dataset
+	if err := tx.Where("project_id = ? AND to_delete = ?", projectID, true).Find(&datasetsToDelete).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to fetch datasets to delete: %w", err)
+	}
+
+	var tablesToDelete []entity.Table
+	if err := tx.Where("dataset_id IN (SELECT id FROM datasets WHERE project_id = ?) AND to_delete = ?", projectID, true).Find(&tablesToDelete).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to fetch tables to delete: %w", err)
+	}
+
+	var columnsToDelete []entity.Column
+	if err := tx.Where("table_id IN (SELECT id FROM tables WHERE dataset_id IN (SELECT id FROM datasets WHERE project_id = ?)) AND to_delete = ?", projectID, true).Find(&columnsToDelete).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to fetch columns to delete: %w", err)
+	}
+
+	if err := tx.Where("project_id = ? AND to_delete = ?", projectID, true).Delete(&entity.Dataset{}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to delete datasets: %w", err)
+	}
+
+	if err := tx.Where("dataset_id IN (SELECT id FROM datasets WHERE project_id = ?) AND to_delete = ?", projectID, true).Delete(&entity.Table{}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to delete tables: %w", err)
+	}
+
+	if err := tx.Where("table_id IN (SELECT id FROM tables WHERE dataset_id IN (SELECT id FROM datasets WHERE project_id = ?)) AND to_delete = ?", projectID, true).Delete(&entity.Column{}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to delete columns: %w", err)
+	}
+
+	// The changelog is computed inside the transaction, so that it is stored
+	// together with the changes it describes
+	newState, err := utils.FetchCurrentState(tx, projectID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to fetch new state for changelog: %w", err)
+	}
+
+	restored := restoredEntities(oldState, newState, startedAt)
+
+	if opts.DryRun {
+		tx.Rollback()
+		changelogs := utils.DiffStates(projectID, nil, oldState, newState, excluded, restored)
+		utils.ApplyCompatibilityPolicies(changelogs, policies)
+		return newSyncResult(nil, true, counts, warnings, changelogs), nil
+	}
+
+	changelogs := utils.DiffStates(projectID, &sync.ID, oldState, newState, excluded, restored)
+	utils.ApplyCompatibilityPolicies(changelogs, policies)
+	if err := utils.RecordChanges(tx, changelogs); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to record changes for changelog: %w", err)
+	}
+
+	renamedColumns, err := recordColumnRenames(tx, projectID, sync.ID, changelogs)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// The sync is only marked as finished once its changelog is stored
+	result := newSyncResult(sync, false, counts, warnings, changelogs)
+	if err := FinishSync(tx, sync, result, nil); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := enqueueSyncEvents(tx, projectID, result); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := notifyWatchers(tx, projectID, sync.ID, changelogs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Batch index documents
+	if len(documentsToIndex) > 0 {
+		_, err := ctx.MeilisearchClient.Index("resources").AddDocuments(documentsToIndex, "id")
+		if err != nil {
+			ctx.Logger.Error("Failed to batch index documents", zap.Error(err))
+			// Continue execution, as the database transaction was successful
+		}
+	}
+
+	// Remove deleted documents from index
+	var idsToDelete []string
+	for _, dataset := range datasetsToDelete {
+		idsToDelete = append(idsToDelete, dataset.ID.String())
+	}
+	for _, table := range tablesToDelete {
+		idsToDelete = append(idsToDelete, table.ID.String())
+	}
+	for _, column := range columnsToDelete {
+		idsToDelete = append(idsToDelete, column.ID.String())
+	}
+
+	if len(idsToDelete) > 0 {
+		_, err := ctx.MeilisearchClient.Index("resources").DeleteDocuments(idsToDelete)
+		if err != nil {
+			ctx.Logger.Error("Failed to delete documents from index", zap.Error(err))
+			// Continue execution, as the database transaction was successful
+		}
+	}
+
+	for i := range renamedColumns {
+		reindexColumn(ctx, &renamedColumns[i])
+	}
+
+	return result, nil
+}
+
+func tableFromMetadata(datasetID uuid.UUID, tblMeta *connectors.TableMetadata) entity.Table {
+	table := entity.Table{
+		Name:             tblMeta.Name,
+		DatasetID:        datasetID,
+		Description:      tblMeta.Description,
+		Type:             tblMeta.Type,
+		RowCount:         tblMeta.RowCount,
+		NumBytes:         tblMeta.NumBytes,
+		Location:         tblMeta.Location,
+		ExpirationTime:   optionalTime(tblMeta.ExpirationTime),
+		CreationTime:     optionalTime(tblMeta.CreationTime),
+		LastModifiedTime: optionalTime(tblMeta.LastModifiedTime),
+	}
+
+	if tblMeta.TimePartitioning != nil {
+		table.TimePartitioning = toJSON(tblMeta.TimePartitioning)
+	}
+	if tblMeta.RangePartitioning != nil {
+		table.RangePartitioning = toJSON(tblMeta.RangePartitioning)
+	}
+	if len(tblMeta.Clustering) > 0 {
+		table.Clustering = toJSON(tblMeta.Clustering)
+	}
+	if len(tblMeta.Labels) > 0 {
+		table.Labels = toJSON(tblMeta.Labels)
+	}
+
+	table.SchemaFingerprint = schemaFingerprint(tblMeta)
+
+	return table
+}
+
+// schemaFingerprint hashes the table description and its full column tree.
+// Tables whose fingerprint and last modified time match the previous sync
+// are not re-read column by column.
+func schemaFingerprint(tblMeta *connectors.TableMetadata) string {
+	hash := sha256.Sum256([]byte(tblMeta.Description + "\x00" + toJSON(tblMeta.Columns)))
+	return hex.EncodeToString(hash[:])
+}
+
+// restoredEntities returns the IDs of datasets, tables and columns that are
+// in the new state but not the old one, and were created before the sync
+// started. The upserts match soft-deleted rows by name, so these are entities
+// that were removed by an earlier sync and have reappeared at the source.
+func restoredEntities(oldState, newState []entity.Dataset, startedAt time.Time) map[uuid.UUID]bool {
+	known := make(map[uuid.UUID]bool)
+	for _, ds := range oldState {
+		known[ds.ID] = true
+		for _, tbl := range ds.Tables {
+			known[tbl.ID] = true
+			for _, col := range tbl.Columns {
+				known[col.ID] = true
+			}
+		}
+	}
+
+	restored := make(map[uuid.UUID]bool)
+	isRestored := func(id uuid.UUID, createdAt time.Time) {
+		if !known[id] && createdAt.Before(startedAt) {
+			restored[id] = true
+		}
+	}
+	for _, ds := range newState {
+		isRestored(ds.ID, ds.CreatedAt)
+		for _, tbl := range ds.Tables {
+			isRestored(tbl.ID, tbl.CreatedAt)
+			for _, col := range tbl.Columns {
+				isRestored(col.ID, col.CreatedAt)
+			}
+		}
+	}
+
+	return restored
+}
+
+// keepTable clears the delete mark of a table that was skipped because it is
+// unchanged, together with the marks of its columns.
+func keepTable(tx *gorm.DB, tableID uuid.UUID) error {
+	if err := tx.Model(&entity.Table{}).Where("id = ?", tableID).Update("to_delete", false).Error; err != nil {
+		return fmt.Errorf("failed to keep unchanged table: %w", err)
+	}
+	if err := tx.Model(&entity.Column{}).Where("table_id = ?", tableID).Update("to_delete", false).Error; err != nil {
+		return fmt.Errorf("failed to keep columns of unchanged table: %w", err)
+	}
+	return nil
+}
+
+// countColumns counts columns including their nested fields.
+func countColumns(columns []connectors.ColumnMetadata) int {
+	count := len(columns)
+	for _, column := range columns {
+		count += countColumns(column.Fields)
+	}
+	return count
+}
+
+// keepDataset clears the delete marks of a dataset that could not be read,
+// together with the marks of its tables and columns.
+func keepDataset(tx *gorm.DB, datasetID uuid.UUID) error {
+	if err := tx.Model(&entity.Dataset{}).Where("id = ?", datasetID).Update("to_delete", false).Error; err != nil {
+		return fmt.Errorf("failed to keep dataset: %w", err)
+	}
+	if err := tx.Model(&entity.Table{}).Where("dataset_id = ?", datasetID).Update("to_delete", false).Error; err != nil {
+		return fmt.Errorf("failed to keep tables of dataset: %w", err)
+	}
+	if err := tx.Model(&entity.Column{}).Where("table_id IN (SELECT id FROM tables WHERE dataset_id = ?)", datasetID).Update("to_delete", false).Error; err != nil {
+		return fmt.Errorf("failed to keep columns of dataset: %w", err)
+	}
+	return nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func toJSON(v interface{}) string {
+	jsonBytes, _ := json.Marshal(v)
+	return string(jsonBytes)
+}
+
+// upsertColumns stores the columns of a table and, recursively, the nested
+// fields of RECORD/STRUCT columns. Nested fields are unique by their dotted
+// path within the table and reference their parent column.
+func upsertColumns(ctx *appcontext.Context, tx *gorm.DB, tableID uuid.UUID, parentID *uuid.UUID, parentPath string, depth int, columns []connectors.ColumnMetadata) ([]map[string]interface{}, error) {
+	var documents []map[string]interface{}
+
+	for position, colMeta := range columns {
+		path := colMeta.Name
+		if parentPath != "" {
+			path = parentPath + "." + colMeta.Name
+		}
+
+		column := entity.Column{
+			Name:        colMeta.Name,
+			Path:        path,
+			Depth:       depth,
+			Position:    position,
+			Type:        colMeta.Type,
+			Mode:        colMeta.Mode,
+			Description: colMeta.Description,
+			TableID:     tableID,
+			ParentID:    parentID,
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "path"}, {Name: "table_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"name":        colMeta.Name,
+				"depth":       depth,
+				"position":    position,
+				"type":        colMeta.Type,
+				"mode":        colMeta.Mode,
+				"description": colMeta.Description,
+				"parent_id":   parentID,
+				"updated_at":  time.Now(),
+				"to_delete":   false,
+				"deleted_at":  nil,
+			}),
+		}).Create(&column).Error; err != nil {
+			return nil, fmt.Errorf("failed to create or update column: %w", err)
+		}
+
+		columnDoc, err := utils.ColumnToDocument(tx, &column)
+		if err != nil {
+			ctx.Logger.Error("Failed to create column document", zap.Error(err), zap.String("column_id", column.ID.String()))
+		} else {
+			documents = append(documents, columnDoc)
+		}
+
+		if len(colMeta.Fields) > 0 {
+			columnID := column.ID
+			nestedDocs, err := upsertColumns(ctx, tx, tableID, &columnID, path, depth+1, colMeta.Fields)
+			if err != nil {
+				return nil, err
+			}
+			documents = append(documents, nestedDocs...)
+		}
+	}
+
+	return documents, nil
+}
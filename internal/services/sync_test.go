@@ -0,0 +1,475 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/connectors"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+	"gorm.io/gorm"
+)
+
+func ordersTable() connectors.TableMetadata {
+	return connectors.TableMetadata{
+		Name:        "orders",
+		Description: "Orders",
+		Type:        connectors.TableTypeTable,
+		Columns: []connectors.ColumnMetadata{
+			{Name: "id", Type: "INT64", Mode: connectors.ColumnModeRequired},
+			{Name: "amount", Type: "INT64", Mode: connectors.ColumnModeNullable},
+			{Name: "customer", Type: "RECORD", Mode: connectors.ColumnModeNullable, Fields: []connectors.ColumnMetadata{
+				{Name: "name", Type: "STRING", Mode: connectors.ColumnModeNullable},
+			}},
+		},
+	}
+}
+
+func customersTable() connectors.TableMetadata {
+	return connectors.TableMetadata{
+		Name: "customers",
+		Type: connectors.TableTypeTable,
+		Columns: []connectors.ColumnMetadata{
+			{Name: "id", Type: "INT64", Mode: connectors.ColumnModeRequired},
+		},
+	}
+}
+
+// runTestSync syncs the project from conn under a new sync.
+func runTestSync(t *testing.T, ctx *appcontext.Context, projectID uuid.UUID, conn connectors.Connector) (*entity.Sync, *SyncResult, error) {
+	t.Helper()
+
+	sync, err := StartSync(ctx.DB, projectID, SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil {
+		t.Fatalf("StartSync: %v", err)
+	}
+
+	result, err := SyncProject(ctx, context.Background(), projectID, sync, conn, SyncOptions{Trigger: entity.SyncTriggerManual})
+	return sync, result, err
+}
+
+// mustSync syncs the project and fails the test on error.
+func mustSync(t *testing.T, ctx *appcontext.Context, projectID uuid.UUID, conn connectors.Connector) (*entity.Sync, *SyncResult) {
+	t.Helper()
+
+	sync, result, err := runTestSync(t, ctx, projectID, conn)
+	if err != nil {
+		t.Fatalf("SyncProject: %v", err)
+	}
+	return sync, result
+}
+
+func syncChangelogs(t *testing.T, db *gorm.DB, syncID uuid.UUID) []entity.Changelog {
+	t.Helper()
+
+	var changelogs []entity.Changelog
+	if err := db.Where("sync_id = ?", syncID).Find(&changelogs).Error; err != nil {
+		t.Fatalf("failed to get changelogs: %v", err)
+	}
+	return changelogs
+}
+
+// findChange returns the changelog entry of the given kind, or nil.
+func findChange(changelogs []entity.Changelog, changeType, entityType, entityName, fieldName string) *entity.Changelog {
+	for i, changelog := range changelogs {
+		if changelog.ChangeType == changeType && changelog.EntityType == entityType && changelog.EntityName == entityName && changelog.FieldName == fieldName {
+			return &changelogs[i]
+		}
+	}
+	return nil
+}
+
+func columnPaths(t *testing.T, db *gorm.DB, tableName string) []string {
+	t.Helper()
+
+	var paths []string
+	if err := db.Model(&entity.Column{}).
+		Where("table_id IN (SELECT id FROM tables WHERE name = ? AND deleted_at IS NULL)", tableName).
+		Order("path").
+		Pluck("path", &paths).Error; err != nil {
+		t.Fatalf("failed to get columns: %v", err)
+	}
+	return paths
+}
+
+func TestSyncProjectInsert(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+
+	sync, result := mustSync(t, ctx, project.ID, conn)
+
+	if got := strings.Join(columnPaths(t, ctx.DB, "orders"), ","); got != "amount,customer,customer.name,id" {
+		t.Errorf("columns = %s, want amount,customer,customer.name,id", got)
+	}
+
+	var nested entity.Column
+	if err := ctx.DB.Where("path = ?", "customer.name").First(&nested).Error; err != nil {
+		t.Fatalf("failed to get nested column: %v", err)
+	}
+	if nested.ParentID == nil || nested.Depth != 1 {
+		t.Errorf("nested column has parent %v and depth %d, want a parent and depth 1", nested.ParentID, nested.Depth)
+	}
+
+	changelogs := syncChangelogs(t, ctx.DB, sync.ID)
+	if len(changelogs) != 1 || findChange(changelogs, "insert", "dataset", "sales", "") == nil {
+		t.Fatalf("changelogs = %+v, want a single dataset insert", changelogs)
+	}
+	if changelogs[0].ProjectID == nil || *changelogs[0].ProjectID != project.ID {
+		t.Errorf("changelog project = %v, want %s", changelogs[0].ProjectID, project.ID)
+	}
+
+	var stored entity.Sync
+	if err := ctx.DB.Where("id = ?", sync.ID).First(&stored).Error; err != nil {
+		t.Fatalf("failed to get sync: %v", err)
+	}
+	if stored.Status != entity.SyncStatusSucceeded || stored.FinishedAt == nil {
+		t.Errorf("sync status = %s, finished at %v, want succeeded and finished", stored.Status, stored.FinishedAt)
+	}
+	if result.Counts.DatasetsScanned != 1 || result.Counts.TablesScanned != 1 || result.Counts.ColumnsScanned != 4 {
+		t.Errorf("counts = %+v, want 1 dataset, 1 table and 4 columns scanned", result.Counts)
+	}
+	if stored.Counts.ColumnsScanned != 4 || stored.Counts.DatasetsChanged != 1 {
+		t.Errorf("stored counts = %+v, want 4 columns scanned and 1 dataset changed", stored.Counts)
+	}
+}
+
+func TestSyncProjectUpdate(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	orders := conn.Table("sales", "orders")
+	orders.Description = "All orders"
+	orders.Columns[1].Type = "STRING"
+	orders.Columns = append(orders.Columns, connectors.ColumnMetadata{Name: "created_at", Type: "TIMESTAMP", Mode: connectors.ColumnModeNullable})
+	conn.AddTable("sales", customersTable())
+
+	sync, result := mustSync(t, ctx, project.ID, conn)
+	changelogs := syncChangelogs(t, ctx.DB, sync.ID)
+
+	if findChange(changelogs, "update", "table", "orders", "Description") == nil {
+		t.Errorf("no table description update in %+v", changelogs)
+	}
+	if findChange(changelogs, "insert", "table", "customers", "") == nil {
+		t.Errorf("no table insert in %+v", changelogs)
+	}
+	if findChange(changelogs, "insert", "column", "created_at", "") == nil {
+		t.Errorf("no column insert in %+v", changelogs)
+	}
+
+	typeChange := findChange(changelogs, "update", "column", "amount", "Type")
+	if typeChange == nil {
+		t.Fatalf("no column type update in %+v", changelogs)
+	}
+	if typeChange.OldValue != `"INT64"` || typeChange.NewValue != `"STRING"` {
+		t.Errorf("type change = %s to %s, want \"INT64\" to \"STRING\"", typeChange.OldValue, typeChange.NewValue)
+	}
+	if typeChange.ParentName != "orders" || typeChange.GrandParentName != "sales" {
+		t.Errorf("type change parents = %s, %s, want orders, sales", typeChange.ParentName, typeChange.GrandParentName)
+	}
+	if len(changelogs) != 4 {
+		t.Errorf("got %d changelogs, want 4: %+v", len(changelogs), changelogs)
+	}
+	if result.Counts.TablesChanged != 2 || result.Counts.ColumnsChanged != 2 {
+		t.Errorf("counts = %+v, want 2 tables and 2 columns changed", result.Counts)
+	}
+}
+
+func TestSyncProjectUnchanged(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	// Writes to the table are not schema changes
+	orders := conn.Table("sales", "orders")
+	orders.NumBytes = 1024
+	orders.LastModifiedTime = time.Now()
+
+	sync, _ := mustSync(t, ctx, project.ID, conn)
+	if changelogs := syncChangelogs(t, ctx.DB, sync.ID); len(changelogs) != 0 {
+		t.Errorf("changelogs = %+v, want none", changelogs)
+	}
+	if got := strings.Join(columnPaths(t, ctx.DB, "orders"), ","); got != "amount,customer,customer.name,id" {
+		t.Errorf("columns = %s, want amount,customer,customer.name,id", got)
+	}
+}
+
+func TestSyncProjectQueryModifiedTimes(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	orders := conn.Table("sales", "orders")
+	orders.LastModifiedTime = time.Now().Truncate(time.Second)
+	mustSync(t, ctx, project.ID, conn)
+
+	// Without the opt-in, every table is described
+	orders.Description = "All orders"
+	sync, _ := mustSync(t, ctx, project.ID, conn)
+	if changelogs := syncChangelogs(t, ctx.DB, sync.ID); len(changelogs) != 1 {
+		t.Fatalf("changelogs = %+v, want the description change", changelogs)
+	}
+
+	if err := ctx.DB.Model(&project).Update("query_modified_times", true).Error; err != nil {
+		t.Fatalf("failed to update project: %v", err)
+	}
+
+	// A table with an unchanged last modified time is skipped
+	orders.Description = "Every order"
+	sync, _ = mustSync(t, ctx, project.ID, conn)
+	if changelogs := syncChangelogs(t, ctx.DB, sync.ID); len(changelogs) != 0 {
+		t.Errorf("changelogs = %+v, want none for a skipped table", changelogs)
+	}
+
+	// If the times cannot be listed, every table is described with a warning
+	conn.ModifiedTimesErr["sales"] = errors.New("permission denied: bigquery.jobs.create")
+	sync, result := mustSync(t, ctx, project.ID, conn)
+	if changelogs := syncChangelogs(t, ctx.DB, sync.ID); len(changelogs) != 1 {
+		t.Errorf("changelogs = %+v, want the description change", changelogs)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].EntityName != "sales" {
+		t.Errorf("warnings = %+v, want one for sales", result.Warnings)
+	}
+}
+
+func TestSyncProjectDelete(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	conn.AddTable("sales", customersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	conn.RemoveTable("sales", "customers")
+	orders := conn.Table("sales", "orders")
+	orders.Columns = orders.Columns[:1]
+
+	sync, _ := mustSync(t, ctx, project.ID, conn)
+	changelogs := syncChangelogs(t, ctx.DB, sync.ID)
+
+	if findChange(changelogs, "delete", "table", "customers", "") == nil {
+		t.Errorf("no table delete in %+v", changelogs)
+	}
+	for _, path := range []string{"amount", "customer", "customer.name"} {
+		if findChange(changelogs, "delete", "column", path, "") == nil {
+			t.Errorf("no delete of column %s in %+v", path, changelogs)
+		}
+	}
+
+	breaking := 0
+	for _, changelog := range changelogs {
+		if changelog.Severity == entity.ChangeSeverityBreaking {
+			breaking++
+		}
+	}
+	var stored entity.Sync
+	if err := ctx.DB.Where("id = ?", sync.ID).First(&stored).Error; err != nil {
+		t.Fatalf("failed to get sync: %v", err)
+	}
+	if breaking == 0 || stored.Counts.BreakingChanges != breaking {
+		t.Errorf("sync has %d breaking changes, want %d", stored.Counts.BreakingChanges, breaking)
+	}
+
+	if got := strings.Join(columnPaths(t, ctx.DB, "orders"), ","); got != "id" {
+		t.Errorf("columns = %s, want id", got)
+	}
+
+	var tables int64
+	ctx.DB.Model(&entity.Table{}).Where("name = ?", "customers").Count(&tables)
+	if tables != 0 {
+		t.Errorf("customers table is still there")
+	}
+	ctx.DB.Unscoped().Model(&entity.Table{}).Where("name = ? AND deleted_at IS NOT NULL", "customers").Count(&tables)
+	if tables != 1 {
+		t.Errorf("customers table is not soft-deleted")
+	}
+}
+
+func TestSyncProjectListError(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	conn.ListErr = errors.New("permission denied")
+
+	_, result, err := runTestSync(t, ctx, project.ID, conn)
+	if err == nil || result != nil {
+		t.Fatalf("SyncProject = %+v, %v, want an error", result, err)
+	}
+
+	var datasets, changelogs int64
+	ctx.DB.Model(&entity.Dataset{}).Count(&datasets)
+	ctx.DB.Model(&entity.Changelog{}).Count(&changelogs)
+	if datasets != 0 || changelogs != 0 {
+		t.Errorf("got %d datasets and %d changelogs, want none", datasets, changelogs)
+	}
+}
+
+func TestSyncProjectDescribeError(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	conn.AddTable("marketing", customersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	conn.Table("sales", "orders").Columns = nil
+	conn.DescribeErr["sales.orders"] = errors.New("quota exceeded")
+	conn.Table("marketing", "customers").Columns = nil
+
+	// A table that cannot be described does not abort the sync, even if the
+	// project does not tolerate failures
+	sync, result := mustSync(t, ctx, project.ID, conn)
+
+	if len(result.Warnings) != 1 || result.Warnings[0].EntityName != "sales.orders" {
+		t.Errorf("warnings = %+v, want one for sales.orders", result.Warnings)
+	}
+	if got := strings.Join(columnPaths(t, ctx.DB, "orders"), ","); got != "amount,customer,customer.name,id" {
+		t.Errorf("orders columns = %s, want amount,customer,customer.name,id", got)
+	}
+
+	// The other tables are still synced
+	changelogs := syncChangelogs(t, ctx.DB, sync.ID)
+	for _, change := range changelogs {
+		if change.EntityType == "column" && change.ChangeType != "delete" {
+			t.Errorf("unexpected change %+v", change)
+		}
+	}
+	if len(changelogs) == 0 {
+		t.Errorf("changelogs = none, want the columns of customers deleted")
+	}
+
+	var stored entity.Sync
+	if err := ctx.DB.Where("id = ?", sync.ID).First(&stored).Error; err != nil {
+		t.Fatalf("failed to get sync: %v", err)
+	}
+	if stored.Status != entity.SyncStatusPartial {
+		t.Errorf("sync status = %s, want partial", stored.Status)
+	}
+}
+
+func TestSyncProjectListTablesError(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	conn.ListTablesErr["sales"] = errors.New("permission denied")
+
+	_, result, err := runTestSync(t, ctx, project.ID, conn)
+	if err == nil || !strings.Contains(err.Error(), "sales") {
+		t.Fatalf("SyncProject = %+v, %v, want an error naming the dataset", result, err)
+	}
+}
+
+func TestSyncProjectStopped(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+
+	sync, err := StartSync(ctx.DB, project.ID, SyncOptions{Trigger: entity.SyncTriggerManual})
+	if err != nil {
+		t.Fatalf("StartSync: %v", err)
+	}
+
+	stop, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := SyncProject(ctx, stop, project.ID, sync, conn, SyncOptions{Trigger: entity.SyncTriggerManual}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SyncProject error = %v, want context.Canceled", err)
+	}
+
+	var datasets int64
+	ctx.DB.Model(&entity.Dataset{}).Count(&datasets)
+	if datasets != 0 {
+		t.Errorf("got %d datasets, want none", datasets)
+	}
+}
+
+func TestSyncProjectTolerateFailures(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	if err := ctx.DB.Model(&project).Update("tolerate_failures", true).Error; err != nil {
+		t.Fatalf("failed to update project: %v", err)
+	}
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	conn.AddTable("marketing", customersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	conn.Table("sales", "orders").Columns = nil
+	conn.DescribeErr["sales.orders"] = errors.New("quota exceeded")
+	conn.Table("marketing", "customers").Columns = nil
+	conn.ListTablesErr["marketing"] = errors.New("permission denied")
+
+	sync, result := mustSync(t, ctx, project.ID, conn)
+
+	if len(result.Warnings) != 2 {
+		t.Errorf("warnings = %+v, want one for the table and one for the dataset", result.Warnings)
+	}
+	if changelogs := syncChangelogs(t, ctx.DB, sync.ID); len(changelogs) != 0 {
+		t.Errorf("changelogs = %+v, want none", changelogs)
+	}
+
+	// Tables that could not be read keep their previous state
+	if got := strings.Join(columnPaths(t, ctx.DB, "orders"), ","); got != "amount,customer,customer.name,id" {
+		t.Errorf("orders columns = %s, want amount,customer,customer.name,id", got)
+	}
+	if got := strings.Join(columnPaths(t, ctx.DB, "customers"), ","); got != "id" {
+		t.Errorf("customers columns = %s, want id", got)
+	}
+
+	var stored entity.Sync
+	if err := ctx.DB.Preload("Warnings").Where("id = ?", sync.ID).First(&stored).Error; err != nil {
+		t.Fatalf("failed to get sync: %v", err)
+	}
+	if stored.Status != entity.SyncStatusPartial || len(stored.Warnings) != 2 {
+		t.Errorf("sync status = %s with %d warnings, want partial with 2", stored.Status, len(stored.Warnings))
+	}
+}
+
+func TestSyncProjectDryRun(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+
+	result, err := SyncProject(ctx, context.Background(), project.ID, nil, conn, SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("SyncProject: %v", err)
+	}
+	if !result.DryRun || findChange(result.Changelogs, "insert", "dataset", "sales", "") == nil {
+		t.Errorf("result = %+v, want a dry run inserting the dataset", result)
+	}
+
+	var datasets, changelogs int64
+	ctx.DB.Model(&entity.Dataset{}).Count(&datasets)
+	ctx.DB.Model(&entity.Changelog{}).Count(&changelogs)
+	if datasets != 0 || changelogs != 0 {
+		t.Errorf("got %d datasets and %d changelogs, want none", datasets, changelogs)
+	}
+}
@@ -0,0 +1,495 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	notificationBatchSize = 500
+	// maxNotificationEmailAttempts is the number of attempts after which
+	// emailing a notification is given up.
+	maxNotificationEmailAttempts = 5
+	notificationEmailBackoff     = time.Minute
+	// notificationEmailLease is how long a claimed notification is hidden
+	// from other workers while it is emailed.
+	notificationEmailLease     = 5 * time.Minute
+	notificationEmailBatchSize = 50
+)
+
+var ErrWatchedEntityNotFound = errors.New("watched entity not found")
+
+// Watcher is a watch on an entity, one of its parents or one of its children,
+// together with the user who watches it. Relation is "self", "parent" or
+// "child", seen from the entity the watchers were listed for.
+type Watcher struct {
+	Watch    entity.Watch `json:"watch"`
+	User     WatcherUser  `json:"user"`
+	Relation string       `json:"relation"`
+}
+
+type WatcherUser struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+}
+
+// watchedEntity is a dataset, table or column with the IDs of its parents.
+type watchedEntity struct {
+	name      string
+	ancestors []uuid.UUID
+}
+
+// resolveWatchedEntity returns the qualified name and the parents of an
+// entity of the project.
+func resolveWatchedEntity(db *gorm.DB, projectID uuid.UUID, entityType string, entityID uuid.UUID) (*watchedEntity, error) {
+	var dataset entity.Dataset
+	var table entity.Table
+	var column entity.Column
+
+	lookup := func(err error) error {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWatchedEntityNotFound
+		}
+		return fmt.Errorf("failed to get watched entity: %w", err)
+	}
+
+	switch entityType {
+	case "dataset":
+		if err := db.Where("id = ? AND project_id = ?", entityID, projectID).First(&dataset).Error; err != nil {
+			return nil, lookup(err)
+		}
+		return &watchedEntity{name: dataset.Name}, nil
+	case "table":
+		if err := db.Where("id = ?", entityID).First(&table).Error; err != nil {
+			return nil, lookup(err)
+		}
+		if err := db.Where("id = ? AND project_id = ?", table.DatasetID, projectID).First(&dataset).Error; err != nil {
+			return nil, lookup(err)
+		}
+		return &watchedEntity{name: dataset.Name + "." + table.Name, ancestors: []uuid.UUID{dataset.ID}}, nil
+	case "column":
+		if err := db.Where("id = ?", entityID).First(&column).Error; err != nil {
+			return nil, lookup(err)
+		}
+		if err := db.Where("id = ?", column.TableID).First(&table).Error; err != nil {
+			return nil, lookup(err)
+		}
+		if err := db.Where("id = ? AND project_id = ?", table.DatasetID, projectID).First(&dataset).Error; err != nil {
+			return nil, lookup(err)
+		}
+		ancestors := []uuid.UUID{table.ID, dataset.ID}
+		// A nested field is also below the RECORD columns it is nested in
+		for parentID := column.ParentID; parentID != nil; {
+			var parent entity.Column
+			if err := db.Where("id = ?", parentID).First(&parent).Error; err != nil {
+				return nil, lookup(err)
+			}
+			ancestors = append(ancestors, parent.ID)
+			parentID = parent.ParentID
+		}
+		return &watchedEntity{name: dataset.Name + "." + table.Name + "." + column.Path, ancestors: ancestors}, nil
+	}
+
+	return nil, ErrWatchedEntityNotFound
+}
+
+func ValidWatchEntityType(entityType string) bool {
+	return entityType == "dataset" || entityType == "table" || entityType == "column"
+}
+
+// WatchEntity makes the user watch an entity of the project, or updates
+// whether they get emails if they already watch it.
+func WatchEntity(db *gorm.DB, userID, projectID uuid.UUID, entityType string, entityID uuid.UUID, email bool) (*entity.Watch, error) {
+	watched, err := resolveWatchedEntity(db, projectID, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	var watch entity.Watch
+	err = db.Where("user_id = ? AND entity_id = ?", userID, entityID).First(&watch).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get watch: %w", err)
+	}
+
+	watch.UserID = userID
+	watch.ProjectID = projectID
+	watch.EntityType = entityType
+	watch.EntityID = entityID
+	watch.EntityName = watched.name
+	watch.Email = email
+
+	if err := db.Save(&watch).Error; err != nil {
+		return nil, fmt.Errorf("failed to save watch: %w", err)
+	}
+
+	return &watch, nil
+}
+
+// EntityWatchers lists the watches that a change to the entity would notify,
+// those on the entity and its parents, and the watches on its children, such
+// as the users who depend on the columns of a table.
+func EntityWatchers(db *gorm.DB, projectID uuid.UUID, entityType string, entityID uuid.UUID) ([]Watcher, error) {
+	watched, err := resolveWatchedEntity(db, projectID, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	relations := map[uuid.UUID]string{entityID: "self"}
+	for _, id := range watched.ancestors {
+		relations[id] = "parent"
+	}
+
+	var children []uuid.UUID
+	switch entityType {
+	case "dataset":
+		if err := db.Model(&entity.Table{}).Where("dataset_id = ?", entityID).Pluck("id", &children).Error; err != nil {
+			return nil, fmt.Errorf("failed to get tables: %w", err)
+		}
+		var columns []uuid.UUID
+		if err := db.Model(&entity.Column{}).Where("table_id IN (SELECT id FROM tables WHERE dataset_id = ? AND deleted_at IS NULL)", entityID).Pluck("id", &columns).Error; err != nil {
+			return nil, fmt.Errorf("failed to get columns: %w", err)
+		}
+		children = append(children, columns...)
+	case "table":
+		if err := db.Model(&entity.Column{}).Where("table_id = ?", entityID).Pluck("id", &children).Error; err != nil {
+			return nil, fmt.Errorf("failed to get columns: %w", err)
+		}
+	case "column":
+		var column entity.Column
+		if err := db.Where("id = ?", entityID).First(&column).Error; err != nil {
+			return nil, fmt.Errorf("failed to get column: %w", err)
+		}
+		var columns []entity.Column
+		if err := db.Where("table_id = ?", column.TableID).Find(&columns).Error; err != nil {
+			return nil, fmt.Errorf("failed to get columns: %w", err)
+		}
+		for _, nested := range columns {
+			if nestedPath(nested.Path, column.Path) {
+				children = append(children, nested.ID)
+			}
+		}
+	}
+	for _, id := range children {
+		relations[id] = "child"
+	}
+
+	var watches []entity.Watch
+	if err := db.Where("project_id = ?", projectID).Order("created_at").Find(&watches).Error; err != nil {
+		return nil, fmt.Errorf("failed to get watches: %w", err)
+	}
+
+	var userIDs []uuid.UUID
+	var related []entity.Watch
+	for _, watch := range watches {
+		if _, ok := relations[watch.EntityID]; ok {
+			related = append(related, watch)
+			userIDs = append(userIDs, watch.UserID)
+		}
+	}
+
+	var users []entity.User
+	if len(userIDs) > 0 {
+		if err := db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, fmt.Errorf("failed to get users: %w", err)
+		}
+	}
+	usersByID := make(map[uuid.UUID]entity.User)
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+
+	watchers := []Watcher{}
+	for _, watch := range related {
+		user := usersByID[watch.UserID]
+		watchers = append(watchers, Watcher{
+			Watch:    watch,
+			User:     WatcherUser{ID: user.ID, Name: user.Name, Email: user.Email},
+			Relation: relations[watch.EntityID],
+		})
+	}
+
+	return watchers, nil
+}
+
+// notifyWatchers creates a notification for every watch on an entity touched
+// by the changelog of a sync, or on one of its parents, including the RECORD
+// columns a changed field is nested in. Notifications of watches that ask for
+// email are queued for RunNotificationEmails.
+func notifyWatchers(tx *gorm.DB, projectID, syncID uuid.UUID, changelogs []entity.Changelog) error {
+	if len(changelogs) == 0 {
+		return nil
+	}
+
+	var watches []entity.Watch
+	if err := tx.Where("project_id = ?", projectID).Find(&watches).Error; err != nil {
+		return fmt.Errorf("failed to get watches: %w", err)
+	}
+	if len(watches) == 0 {
+		return nil
+	}
+
+	watchedColumns, err := watchedColumnsByTable(tx, watches)
+	if err != nil {
+		return err
+	}
+
+	changes := make(map[uuid.UUID]int)
+	breaking := make(map[uuid.UUID]int)
+	for _, changelog := range changelogs {
+		ids := []*uuid.UUID{&changelog.EntityID, changelog.ParentID, changelog.GrandParentID}
+		// Changes to the nested fields of a RECORD column count for the column
+		if changelog.EntityType == "column" && changelog.ParentID != nil {
+			for _, column := range watchedColumns[*changelog.ParentID] {
+				if column.ID != changelog.EntityID && changeWithin(&changelog, column.Path) {
+					id := column.ID
+					ids = append(ids, &id)
+				}
+			}
+		}
+		for _, id := range ids {
+			if id == nil {
+				continue
+			}
+			changes[*id]++
+			if changelog.Severity == entity.ChangeSeverityBreaking {
+				breaking[*id]++
+			}
+		}
+	}
+
+	now := time.Now()
+	var notifications []entity.Notification
+	for _, watch := range watches {
+		count := changes[watch.EntityID]
+		if count == 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("%d changes to %s %s", count, watch.EntityType, watch.EntityName)
+		if count == 1 {
+			message = fmt.Sprintf("1 change to %s %s", watch.EntityType, watch.EntityName)
+		}
+		if breaking[watch.EntityID] > 0 {
+			message += fmt.Sprintf(", %d breaking", breaking[watch.EntityID])
+		}
+
+		var emailAt *time.Time
+		if watch.Email {
+			emailAt = &now
+		}
+
+		notifications = append(notifications, entity.Notification{
+			UserID:      watch.UserID,
+			ProjectID:   projectID,
+			WatchID:     watch.ID,
+			SyncID:      &syncID,
+			EntityType:  watch.EntityType,
+			EntityID:    watch.EntityID,
+			EntityName:  watch.EntityName,
+			Message:     message,
+			ChangeCount: count,
+			EmailAt:     emailAt,
+		})
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := tx.CreateInBatches(notifications, notificationBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %w", err)
+	}
+
+	return nil
+}
+
+// watchedColumnsByTable returns the watched columns, grouped by table.
+func watchedColumnsByTable(db *gorm.DB, watches []entity.Watch) (map[uuid.UUID][]entity.Column, error) {
+	var ids []uuid.UUID
+	for _, watch := range watches {
+		if watch.EntityType == "column" {
+			ids = append(ids, watch.EntityID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var columns []entity.Column
+	if err := db.Unscoped().Where("id IN ?", ids).Find(&columns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get watched columns: %w", err)
+	}
+
+	byTable := make(map[uuid.UUID][]entity.Column)
+	for _, column := range columns {
+		byTable[column.TableID] = append(byTable[column.TableID], column)
+	}
+	return byTable, nil
+}
+
+// changeWithin reports whether a column changelog entry is about the column
+// at path or one of its nested fields, before or after a rename.
+func changeWithin(changelog *entity.Changelog, path string) bool {
+	if changelog.EntityName == path || nestedPath(changelog.EntityName, path) {
+		return true
+	}
+	if changelog.ChangeType == "rename" {
+		var oldPath string
+		json.Unmarshal([]byte(changelog.OldValue), &oldPath)
+		return oldPath == path || nestedPath(oldPath, path)
+	}
+	return false
+}
+
+// nestedPath reports whether path is a field nested in the column at parent.
+func nestedPath(path, parent string) bool {
+	return strings.HasPrefix(path, parent+".")
+}
+
+// RunNotificationEmails emails due notifications every interval until stop
+// is done.
+func RunNotificationEmails(ctx *appcontext.Context, stop context.Context, interval time.Duration, mailer Mailer) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := sendDueNotificationEmails(ctx, mailer); err != nil {
+			ctx.Logger.Error("Failed to email notifications", zap.Error(err))
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDueNotificationEmails claims due notifications by moving their email_at
+// past notificationEmailLease, so that no row lock is held while talking to
+// the mail server and notifications of a crashed worker are picked up again.
+func sendDueNotificationEmails(ctx *appcontext.Context, mailer Mailer) error {
+	var notifications []entity.Notification
+
+	err := ctx.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("email_at <= ?", now).
+			Order("email_at").
+			Limit(notificationEmailBatchSize).
+			Find(&notifications).Error; err != nil {
+			return fmt.Errorf("failed to fetch due notification emails: %w", err)
+		}
+		if len(notifications) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(notifications))
+		for i, notification := range notifications {
+			ids[i] = notification.ID
+		}
+		if err := tx.Model(&entity.Notification{}).Where("id IN ?", ids).Update("email_at", now.Add(notificationEmailLease)).Error; err != nil {
+			return fmt.Errorf("failed to claim notification emails: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range notifications {
+		if err := emailNotification(ctx, mailer, &notifications[i]); err != nil {
+			ctx.Logger.Error("Failed to record notification email", zap.Error(err), zap.String("notification_id", notifications[i].ID.String()))
+		}
+	}
+
+	return nil
+}
+
+// emailNotification makes one attempt at emailing a notification and records
+// its outcome. Failed attempts are retried with exponential backoff until
+// maxNotificationEmailAttempts.
+func emailNotification(ctx *appcontext.Context, mailer Mailer, notification *entity.Notification) error {
+	updates := map[string]interface{}{"email_attempts": notification.EmailAttempts + 1}
+
+	var user entity.User
+	err := ctx.DB.Where("id = ?", notification.UserID).First(&user).Error
+	if err == nil {
+		err = mailer.Send(notificationMessage(&user, notification))
+	} else {
+		err = fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		updates["emailed_at"] = now
+		updates["email_at"] = nil
+	case notification.EmailAttempts+1 >= maxNotificationEmailAttempts:
+		ctx.Logger.Error("Failed to email notification, giving up", zap.Error(err), zap.String("notification_id", notification.ID.String()))
+		updates["email_at"] = nil
+	default:
+		ctx.Logger.Error("Failed to email notification", zap.Error(err), zap.String("notification_id", notification.ID.String()))
+		updates["email_at"] = now.Add(notificationEmailBackoff << notification.EmailAttempts)
+	}
+
+	if err := ctx.DB.Model(notification).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+
+	return nil
+}
+
+func notificationMessage(user *entity.User, notification *entity.Notification) MailMessage {
+	url := fmt.Sprintf("%s/projects/%s", os.Getenv("FRONTEND_HOST"), notification.ProjectID)
+
+	return MailMessage{
+		ToName:    user.Name,
+		ToAddress: user.Email,
+		Subject:   notification.Message,
+		PlainText: fmt.Sprintf("%s.\n\nSee the changelog at %s\n\nYou receive this email because you watch %s in Katalog.", notification.Message, url, notification.EntityName),
+		HTML: fmt.Sprintf(`<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+			<p>%s.</p>
+			<a href="%s" style="display: inline-block; background-color: #2563eb; color: #ffffff; text-decoration: none; padding: 12px 24px; border-radius: 4px; font-weight: bold;">View changelog</a>
+			<p style="margin-top: 30px; font-size: 12px; color: #7f8c8d;">You receive this email because you watch %s in Katalog.</p>
+		</div>`, html.EscapeString(notification.Message), html.EscapeString(url), html.EscapeString(notification.EntityName)),
+	}
+}
+
+// moveWatches moves the watches on a column to the column it was renamed to.
+// Users who already watch the new column keep that watch.
+func moveWatches(tx *gorm.DB, rename *entity.ColumnRename) error {
+	if err := tx.Unscoped().
+		Where("entity_id = ? AND user_id IN (?)", rename.OldColumnID, tx.Model(&entity.Watch{}).Select("user_id").Where("entity_id = ?", rename.NewColumnID)).
+		Delete(&entity.Watch{}).Error; err != nil {
+		return fmt.Errorf("failed to remove duplicate watches: %w", err)
+	}
+
+	var watches []entity.Watch
+	if err := tx.Where("entity_id = ?", rename.OldColumnID).Find(&watches).Error; err != nil {
+		return fmt.Errorf("failed to get watches: %w", err)
+	}
+	for _, watch := range watches {
+		if err := tx.Model(&watch).Updates(map[string]interface{}{
+			"entity_id":   rename.NewColumnID,
+			"entity_name": strings.TrimSuffix(watch.EntityName, rename.OldPath) + rename.NewPath,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to move watch: %w", err)
+		}
+	}
+
+	return nil
+}
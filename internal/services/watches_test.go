@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func watchColumn(t *testing.T, ctx *appcontext.Context, project entity.Project, userID uuid.UUID, path string) *entity.Watch {
+	t.Helper()
+
+	var column entity.Column
+	if err := ctx.DB.Where("path = ?", path).First(&column).Error; err != nil {
+		t.Fatalf("failed to get column %s: %v", path, err)
+	}
+	watch, err := WatchEntity(ctx.DB, userID, project.ID, "column", column.ID, false)
+	if err != nil {
+		t.Fatalf("WatchEntity: %v", err)
+	}
+	return watch
+}
+
+func syncNotifications(t *testing.T, ctx *appcontext.Context, sync *entity.Sync) map[uuid.UUID]entity.Notification {
+	t.Helper()
+
+	var notifications []entity.Notification
+	if err := ctx.DB.Where("sync_id = ?", sync.ID).Find(&notifications).Error; err != nil {
+		t.Fatalf("failed to get notifications: %v", err)
+	}
+	byWatch := make(map[uuid.UUID]entity.Notification)
+	for _, notification := range notifications {
+		byWatch[notification.WatchID] = notification
+	}
+	return byWatch
+}
+
+func TestNotifyWatchersOfNestedFields(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	record := watchColumn(t, ctx, project, user.ID, "customer")
+	field := watchColumn(t, ctx, project, user.ID, "customer.name")
+	amount := watchColumn(t, ctx, project, user.ID, "amount")
+
+	orders := conn.Table("sales", "orders")
+	orders.Columns[2].Fields[0].Type = "INT64"
+	sync, _ := mustSync(t, ctx, project.ID, conn)
+
+	notifications := syncNotifications(t, ctx, sync)
+	if notification, ok := notifications[record.ID]; !ok || notification.ChangeCount != 1 {
+		t.Errorf("RECORD column notification = %+v, want 1 change", notification)
+	}
+	if _, ok := notifications[field.ID]; !ok {
+		t.Errorf("no notification for the nested field")
+	}
+	if _, ok := notifications[amount.ID]; ok {
+		t.Errorf("unchanged column was notified")
+	}
+
+	watchers, err := EntityWatchers(ctx.DB, project.ID, "column", field.EntityID)
+	if err != nil {
+		t.Fatalf("EntityWatchers: %v", err)
+	}
+	relations := make(map[uuid.UUID]string)
+	for _, watcher := range watchers {
+		relations[watcher.Watch.ID] = watcher.Relation
+	}
+	if relations[record.ID] != "parent" || relations[field.ID] != "self" || len(relations) != 2 {
+		t.Errorf("watchers of the nested field = %v, want the field and its RECORD column", relations)
+	}
+}
+
+func TestConfirmedRenameMovesWatches(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+	other := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+	both := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	watch := watchColumn(t, ctx, project, user.ID, "customer.name")
+	record := watchColumn(t, ctx, project, other.ID, "customer")
+	watchColumn(t, ctx, project, both.ID, "customer.name")
+
+	orders := conn.Table("sales", "orders")
+	orders.Columns[2].Name = "client"
+	sync, _ := mustSync(t, ctx, project.ID, conn)
+
+	// The watch on the RECORD column hears about its own rename
+	if _, ok := syncNotifications(t, ctx, sync)[record.ID]; !ok {
+		t.Errorf("no notification of the renamed RECORD column")
+	}
+
+	// A user who already watches the new column keeps a single watch
+	watchColumn(t, ctx, project, both.ID, "client.name")
+
+	var renames []entity.ColumnRename
+	if err := ctx.DB.Where("sync_id = ?", sync.ID).Find(&renames).Error; err != nil {
+		t.Fatalf("failed to get renames: %v", err)
+	}
+	if len(renames) != 2 {
+		t.Fatalf("got %d renames, want 2", len(renames))
+	}
+	for _, rename := range renames {
+		if _, err := ResolveColumnRename(ctx, user.ID, rename.ID, rename.NewPath == "client.name"); err != nil {
+			t.Fatalf("ResolveColumnRename: %v", err)
+		}
+	}
+
+	var moved entity.Watch
+	if err := ctx.DB.Where("id = ?", watch.ID).First(&moved).Error; err != nil {
+		t.Fatalf("failed to get watch: %v", err)
+	}
+	var renamed entity.Column
+	if err := ctx.DB.Where("path = ?", "client.name").First(&renamed).Error; err != nil {
+		t.Fatalf("failed to get column: %v", err)
+	}
+	if moved.EntityID != renamed.ID || !strings.HasSuffix(moved.EntityName, ".orders.client.name") {
+		t.Errorf("watch on %s (%s), want it moved to client.name (%s)", moved.EntityName, moved.EntityID, renamed.ID)
+	}
+
+	var watches []entity.Watch
+	ctx.DB.Where("user_id = ?", both.ID).Find(&watches)
+	if len(watches) != 1 || watches[0].EntityID != renamed.ID {
+		t.Errorf("watches of a user who watched both columns = %+v, want one on client.name", watches)
+	}
+
+	// A rejected rename leaves the watch on the old column
+	var kept entity.Watch
+	if err := ctx.DB.Where("id = ?", record.ID).First(&kept).Error; err != nil {
+		t.Fatalf("failed to get watch: %v", err)
+	}
+	if kept.EntityID != record.EntityID {
+		t.Errorf("watch of a rejected rename moved to %s", kept.EntityID)
+	}
+
+	orders.Columns[2].Fields[0].Type = "INT64"
+	sync, _ = mustSync(t, ctx, project.ID, conn)
+	if _, ok := syncNotifications(t, ctx, sync)[watch.ID]; !ok {
+		t.Errorf("moved watch was not notified of a change to the renamed column")
+	}
+}
+
+func TestNotificationEmails(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	project := testutil.CreateProject(t, ctx.DB)
+	user := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+	other := testutil.CreateUser(t, ctx.DB, project.CompanyID)
+
+	conn := testutil.NewFakeConnector()
+	conn.AddTable("sales", ordersTable())
+	mustSync(t, ctx, project.ID, conn)
+
+	var column entity.Column
+	if err := ctx.DB.Where("path = ?", "amount").First(&column).Error; err != nil {
+		t.Fatalf("failed to get column: %v", err)
+	}
+	emailed, err := WatchEntity(ctx.DB, user.ID, project.ID, "column", column.ID, true)
+	if err != nil {
+		t.Fatalf("WatchEntity: %v", err)
+	}
+	silent := watchColumn(t, ctx, project, other.ID, "amount")
+
+	conn.Table("sales", "orders").Columns[1].Type = "FLOAT64"
+	sync, _ := mustSync(t, ctx, project.ID, conn)
+
+	// The sync only queues the email
+	notifications := syncNotifications(t, ctx, sync)
+	if notification := notifications[emailed.ID]; notification.EmailAt == nil || notification.EmailedAt != nil {
+		t.Fatalf("notification = %+v, want it queued for email", notification)
+	}
+	if notification := notifications[silent.ID]; notification.EmailAt != nil {
+		t.Errorf("notification of a watch without email was queued")
+	}
+
+	failing := &fakeMailer{err: errors.New("mail server unavailable")}
+	if err := sendDueNotificationEmails(ctx, failing); err != nil {
+		t.Fatalf("sendDueNotificationEmails: %v", err)
+	}
+	postponed := syncNotifications(t, ctx, sync)[emailed.ID]
+	if postponed.EmailAttempts != 1 || postponed.EmailAt == nil || !postponed.EmailAt.After(time.Now()) {
+		t.Fatalf("notification = %+v, want it postponed after a failed attempt", postponed)
+	}
+
+	mailer := &fakeMailer{}
+	if err := sendDueNotificationEmails(ctx, mailer); err != nil {
+		t.Fatalf("sendDueNotificationEmails: %v", err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Fatalf("postponed notification was emailed before its retry")
+	}
+
+	if err := ctx.DB.Model(&postponed).Update("email_at", time.Now()).Error; err != nil {
+		t.Fatalf("failed to update notification: %v", err)
+	}
+	// The claim is committed before the email is sent
+	mailer.onSend = func() {
+		if claimed := syncNotifications(t, ctx, sync)[emailed.ID]; claimed.EmailAt == nil || !claimed.EmailAt.After(time.Now()) {
+			t.Errorf("notification was not claimed while its email was sent")
+		}
+	}
+	if err := sendDueNotificationEmails(ctx, mailer); err != nil {
+		t.Fatalf("sendDueNotificationEmails: %v", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].ToAddress != user.Email {
+		t.Fatalf("sent %+v, want one email to %s", mailer.sent, user.Email)
+	}
+	if sent := syncNotifications(t, ctx, sync)[emailed.ID]; sent.EmailAt != nil || sent.EmailedAt == nil {
+		t.Errorf("notification = %+v, want it marked as emailed", sent)
+	}
+}
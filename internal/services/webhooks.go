@@ -0,0 +1,398 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxWebhookAttempts is the number of attempts after which a delivery is
+	// given up. With the backoff below, the last attempt is made about two
+	// hours after the event.
+	maxWebhookAttempts = 8
+	webhookBackoff     = time.Minute
+	// webhookLease is how long a claimed delivery is hidden from other
+	// workers while it is being sent.
+	webhookLease     = 5 * time.Minute
+	webhookBatchSize = 50
+)
+
+// webhookClient refuses to connect to private addresses, also when a host
+// resolves to a different address than it did when the webhook was saved.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+					return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+var (
+	ErrWebhookNotFound          = errors.New("webhook not found")
+	ErrWebhookDisabled          = errors.New("webhook is disabled")
+	ErrWebhookAddressNotAllowed = errors.New("webhook address is not public")
+)
+
+// WebhookEvents lists the events a webhook can subscribe to.
+var WebhookEvents = []string{
+	entity.WebhookEventSyncCompleted,
+	entity.WebhookEventSyncFailed,
+	entity.WebhookEventBreakingChange,
+	entity.WebhookEventEntityDeleted,
+}
+
+// WebhookPayload is the JSON body posted to webhooks.
+type WebhookPayload struct {
+	ID         uuid.UUID   `json:"id"`
+	Event      string      `json:"event"`
+	ProjectID  uuid.UUID   `json:"project_id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+func ValidWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscribes reports whether the webhook receives the event.
+func WebhookSubscribes(webhook *entity.Webhook, event string) bool {
+	for _, e := range strings.Split(webhook.Events, ",") {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateWebhookHost checks that the host of a webhook URL only resolves to
+// public addresses, so that webhooks cannot be used to reach the internal
+// network of the server.
+func ValidateWebhookHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicIP(ip) {
+			return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrWebhookAddressNotAllowed, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// NewWebhookSecret returns a random secret to sign the payloads of a webhook
+// with.
+func NewWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 of the timestamp, a
+// dot and the payload, as sent in the X-Katalog-Signature header. The
+// timestamp is sent in the X-Katalog-Timestamp header as Unix seconds, so that
+// receivers can reject replayed deliveries signed minutes ago.
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// EnqueueWebhookEvent queues a delivery of the event for every enabled webhook
+// of the project that subscribes to it. Run in the transaction that records
+// the event, the deliveries are only queued if the event is persisted.
+func EnqueueWebhookEvent(db *gorm.DB, projectID uuid.UUID, event string, data interface{}) error {
+	var webhooks []entity.Webhook
+	if err := db.Where("project_id = ? AND enabled = ?", projectID, true).Find(&webhooks).Error; err != nil {
+		return fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	now := time.Now()
+	var deliveries []entity.WebhookDelivery
+	for i := range webhooks {
+		if !WebhookSubscribes(&webhooks[i], event) {
+			continue
+		}
+
+		payload, err := json.Marshal(WebhookPayload{
+			ID:         uuid.New(),
+			Event:      event,
+			ProjectID:  projectID,
+			OccurredAt: now,
+			Data:       data,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		}
+
+		deliveries = append(deliveries, entity.WebhookDelivery{
+			WebhookID:     webhooks[i].ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        entity.WebhookDeliveryStatusPending,
+			NextAttemptAt: &now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := db.Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// enqueueSyncEvents queues the events of a successful sync: its completion,
+// and its breaking changes and deleted entities, if any.
+func enqueueSyncEvents(db *gorm.DB, projectID uuid.UUID, result *SyncResult) error {
+	var breaking, deleted []entity.Changelog
+	for _, changelog := range result.Changelogs {
+		if changelog.Severity == entity.ChangeSeverityBreaking {
+			breaking = append(breaking, changelog)
+		}
+		if changelog.ChangeType == "delete" {
+			deleted = append(deleted, changelog)
+		}
+	}
+
+	if err := EnqueueWebhookEvent(db, projectID, entity.WebhookEventSyncCompleted, map[string]interface{}{
+		"sync":   result.Sync,
+		"totals": result.Totals,
+	}); err != nil {
+		return err
+	}
+
+	if len(breaking) > 0 {
+		if err := EnqueueWebhookEvent(db, projectID, entity.WebhookEventBreakingChange, map[string]interface{}{
+			"sync_id": result.Sync.ID,
+			"changes": breaking,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(deleted) > 0 {
+		if err := EnqueueWebhookEvent(db, projectID, entity.WebhookEventEntityDeleted, map[string]interface{}{
+			"sync_id": result.Sync.ID,
+			"changes": deleted,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RedeliverWebhook queues the payload of a past delivery again, as a new
+// delivery.
+func RedeliverWebhook(db *gorm.DB, webhookID, deliveryID uuid.UUID) (*entity.WebhookDelivery, error) {
+	var original entity.WebhookDelivery
+	if err := db.Where("id = ? AND webhook_id = ?", deliveryID, webhookID).First(&original).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	now := time.Now()
+	delivery := entity.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        entity.WebhookDeliveryStatusPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// RunWebhookDeliveries sends due webhook deliveries every interval until stop
+// is done.
+func RunWebhookDeliveries(ctx *appcontext.Context, stop context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := sendDueWebhooks(ctx); err != nil {
+			ctx.Logger.Error("Failed to send webhooks", zap.Error(err))
+		}
+
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDueWebhooks claims due deliveries by moving their next attempt past
+// the lease, so that the requests are not sent while holding row locks and
+// deliveries of a crashed worker are picked up again.
+func sendDueWebhooks(ctx *appcontext.Context) error {
+	var deliveries []entity.WebhookDelivery
+
+	err := ctx.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", entity.WebhookDeliveryStatusPending, now).
+			Order("next_attempt_at").
+			Limit(webhookBatchSize).
+			Find(&deliveries).Error; err != nil {
+			return fmt.Errorf("failed to fetch due webhook deliveries: %w", err)
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		if err := tx.Model(&entity.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(webhookLease)).Error; err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		if err := sendWebhook(ctx, &deliveries[i]); err != nil {
+			ctx.Logger.Error("Failed to record webhook delivery", zap.Error(err), zap.String("delivery_id", deliveries[i].ID.String()))
+		}
+	}
+
+	return nil
+}
+
+// sendWebhook makes one attempt at a delivery and records its outcome. Failed
+// attempts are retried with exponential backoff until maxWebhookAttempts.
+func sendWebhook(ctx *appcontext.Context, delivery *entity.WebhookDelivery) error {
+	updates := map[string]interface{}{"attempts": delivery.Attempts + 1}
+
+	var webhook entity.Webhook
+	err := ctx.DB.Where("id = ?", delivery.WebhookID).First(&webhook).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrWebhookNotFound
+	}
+	if err == nil && !webhook.Enabled {
+		err = ErrWebhookDisabled
+	}
+
+	var status int
+	if err == nil {
+		status, err = postWebhook(&webhook, delivery)
+		updates["response_status"] = status
+	}
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		updates["status"] = entity.WebhookDeliveryStatusSucceeded
+		updates["error"] = ""
+		updates["delivered_at"] = now
+		updates["next_attempt_at"] = nil
+	case errors.Is(err, ErrWebhookNotFound) || errors.Is(err, ErrWebhookDisabled) || delivery.Attempts+1 >= maxWebhookAttempts:
+		updates["status"] = entity.WebhookDeliveryStatusFailed
+		updates["error"] = err.Error()
+		updates["next_attempt_at"] = nil
+	default:
+		updates["error"] = err.Error()
+		updates["next_attempt_at"] = now.Add(webhookBackoff << delivery.Attempts)
+	}
+
+	if err := ctx.DB.Model(delivery).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func postWebhook(webhook *entity.Webhook, delivery *entity.WebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Katalog-Webhooks")
+	req.Header.Set("X-Katalog-Event", delivery.Event)
+	req.Header.Set("X-Katalog-Delivery", delivery.ID.String())
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("X-Katalog-Timestamp", timestamp)
+	req.Header.Set("X-Katalog-Signature", SignWebhookPayload(webhook.Secret, timestamp, payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The response body is not stored, it may hold anything the receiver
+	// returns, e.g. pages of an internal service
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
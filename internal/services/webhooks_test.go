@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/kerem-kaynak/katalog/internal/testutil"
+)
+
+func TestValidateWebhookHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"localhost", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		err := ValidateWebhookHost(context.Background(), test.host)
+		if test.allowed && err != nil {
+			t.Errorf("ValidateWebhookHost(%q) = %v, want nil", test.host, err)
+		}
+		if !test.allowed && !errors.Is(err, ErrWebhookAddressNotAllowed) {
+			t.Errorf("ValidateWebhookHost(%q) = %v, want ErrWebhookAddressNotAllowed", test.host, err)
+		}
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request to %s reached the server", r.URL)
+	}))
+	defer server.Close()
+
+	_, err := webhookClient.Post(server.URL, "application/json", strings.NewReader("{}"))
+	if !errors.Is(err, ErrWebhookAddressNotAllowed) {
+		t.Errorf("Post = %v, want ErrWebhookAddressNotAllowed", err)
+	}
+}
+
+// webhookReceiver serves webhook deliveries with the given status and
+// records the requests it received.
+type webhookReceiver struct {
+	*httptest.Server
+	requests []*http.Request
+	bodies   []string
+}
+
+func newWebhookReceiver(t *testing.T, status int) *webhookReceiver {
+	receiver := &webhookReceiver{}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.requests = append(receiver.requests, r)
+		receiver.bodies = append(receiver.bodies, string(body))
+		w.WriteHeader(status)
+		io.WriteString(w, "internal details of the receiver")
+	}))
+	t.Cleanup(receiver.Close)
+
+	// The receiver listens on a loopback address, which webhookClient refuses
+	client := webhookClient
+	webhookClient = receiver.Client()
+	t.Cleanup(func() { webhookClient = client })
+
+	return receiver
+}
+
+func createDelivery(t *testing.T, ctx *appcontext.Context, url string, enabled bool) *entity.WebhookDelivery {
+	t.Helper()
+
+	project := testutil.CreateProject(t, ctx.DB)
+	webhook := entity.Webhook{ProjectID: project.ID, URL: url, Secret: "secret", Events: entity.WebhookEventSyncCompleted, Enabled: true}
+	if err := ctx.DB.Create(&webhook).Error; err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	if !enabled {
+		if err := ctx.DB.Model(&webhook).Update("enabled", false).Error; err != nil {
+			t.Fatalf("failed to disable webhook: %v", err)
+		}
+	}
+
+	delivery := entity.WebhookDelivery{WebhookID: webhook.ID, Event: entity.WebhookEventSyncCompleted, Payload: `{"event":"sync.completed"}`, Status: entity.WebhookDeliveryStatusPending}
+	if err := ctx.DB.Create(&delivery).Error; err != nil {
+		t.Fatalf("failed to create delivery: %v", err)
+	}
+	return &delivery
+}
+
+func storedDelivery(t *testing.T, ctx *appcontext.Context, delivery *entity.WebhookDelivery) entity.WebhookDelivery {
+	t.Helper()
+
+	var stored entity.WebhookDelivery
+	if err := ctx.DB.Where("id = ?", delivery.ID).First(&stored).Error; err != nil {
+		t.Fatalf("failed to get delivery: %v", err)
+	}
+	return stored
+}
+
+func TestSendWebhookSignsTimestamp(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	delivery := createDelivery(t, ctx, receiver.URL, true)
+
+	if err := sendWebhook(ctx, delivery); err != nil {
+		t.Fatalf("sendWebhook: %v", err)
+	}
+	if len(receiver.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(receiver.requests))
+	}
+
+	request := receiver.requests[0]
+	timestamp := request.Header.Get("X-Katalog-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(seconds, 0)) > time.Minute {
+		t.Errorf("timestamp = %q, want the current Unix time", timestamp)
+	}
+	if got, want := request.Header.Get("X-Katalog-Signature"), SignWebhookPayload("secret", timestamp, []byte(receiver.bodies[0])); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+	// A replayed payload with a fresh timestamp does not carry a valid signature
+	if SignWebhookPayload("secret", strconv.FormatInt(seconds+60, 10), []byte(receiver.bodies[0])) == request.Header.Get("X-Katalog-Signature") {
+		t.Errorf("signature does not depend on the timestamp")
+	}
+
+	if stored := storedDelivery(t, ctx, delivery); stored.Status != entity.WebhookDeliveryStatusSucceeded {
+		t.Errorf("delivery status = %s, want succeeded", stored.Status)
+	}
+}
+
+func TestSendWebhookFailure(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+	delivery := createDelivery(t, ctx, receiver.URL, true)
+
+	if err := sendWebhook(ctx, delivery); err != nil {
+		t.Fatalf("sendWebhook: %v", err)
+	}
+
+	stored := storedDelivery(t, ctx, delivery)
+	if stored.Status != entity.WebhookDeliveryStatusPending || stored.ResponseStatus != http.StatusInternalServerError || stored.NextAttemptAt == nil {
+		t.Errorf("delivery = %s with status %d, want a pending retry after status 500", stored.Status, stored.ResponseStatus)
+	}
+	if strings.Contains(stored.Error, "internal details") {
+		t.Errorf("delivery error %q holds the response body", stored.Error)
+	}
+}
+
+func TestSendWebhookDisabled(t *testing.T) {
+	ctx := testutil.NewContext(t)
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	delivery := createDelivery(t, ctx, receiver.URL, false)
+
+	if err := sendWebhook(ctx, delivery); err != nil {
+		t.Fatalf("sendWebhook: %v", err)
+	}
+	if len(receiver.requests) != 0 {
+		t.Errorf("receiver got %d requests from a disabled webhook", len(receiver.requests))
+	}
+	if stored := storedDelivery(t, ctx, delivery); stored.Status != entity.WebhookDeliveryStatusFailed || stored.Error != ErrWebhookDisabled.Error() {
+		t.Errorf("delivery = %s with error %q, want failed as disabled", stored.Status, stored.Error)
+	}
+}
@@ -0,0 +1,106 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kerem-kaynak/katalog/internal/connectors"
+)
+
+// FakeConnector serves datasets and tables from memory. Errors can be set per
+// call: ListErr fails ListDatasets, ListTablesErr the listing of a dataset's
+// tables, ModifiedTimesErr the listing of their last modified times and
+// DescribeErr the description of a "dataset.table".
+type FakeConnector struct {
+	ListErr          error
+	ListTablesErr    map[string]error
+	ModifiedTimesErr map[string]error
+	DescribeErr      map[string]error
+
+	datasets []connectors.DatasetMetadata
+	tables   map[string][]connectors.TableMetadata
+}
+
+func NewFakeConnector() *FakeConnector {
+	return &FakeConnector{
+		ListTablesErr:    map[string]error{},
+		ModifiedTimesErr: map[string]error{},
+		DescribeErr:      map[string]error{},
+		tables:           map[string][]connectors.TableMetadata{},
+	}
+}
+
+// AddTable adds a table, and its dataset if it is new.
+func (c *FakeConnector) AddTable(dataset string, table connectors.TableMetadata) {
+	if _, exists := c.tables[dataset]; !exists {
+		c.datasets = append(c.datasets, connectors.DatasetMetadata{Name: dataset})
+	}
+	c.tables[dataset] = append(c.tables[dataset], table)
+}
+
+// RemoveTable removes a table, keeping its dataset.
+func (c *FakeConnector) RemoveTable(dataset, table string) {
+	tables := c.tables[dataset][:0]
+	for _, t := range c.tables[dataset] {
+		if t.Name != table {
+			tables = append(tables, t)
+		}
+	}
+	c.tables[dataset] = tables
+}
+
+// Table returns a table to be changed in place.
+func (c *FakeConnector) Table(dataset, table string) *connectors.TableMetadata {
+	for i := range c.tables[dataset] {
+		if c.tables[dataset][i].Name == table {
+			return &c.tables[dataset][i]
+		}
+	}
+	return nil
+}
+
+func (c *FakeConnector) ListDatasets(ctx context.Context) ([]connectors.DatasetMetadata, error) {
+	if c.ListErr != nil {
+		return nil, c.ListErr
+	}
+	return c.datasets, nil
+}
+
+func (c *FakeConnector) ListTables(ctx context.Context, dataset string) ([]connectors.TableRef, error) {
+	if err := c.ListTablesErr[dataset]; err != nil {
+		return nil, err
+	}
+	var refs []connectors.TableRef
+	for _, table := range c.tables[dataset] {
+		refs = append(refs, connectors.TableRef{Name: table.Name})
+	}
+	return refs, nil
+}
+
+func (c *FakeConnector) ListModifiedTimes(ctx context.Context, dataset string) (map[string]time.Time, error) {
+	if err := c.ModifiedTimesErr[dataset]; err != nil {
+		return nil, err
+	}
+	modifiedTimes := make(map[string]time.Time)
+	for _, table := range c.tables[dataset] {
+		modifiedTimes[table.Name] = table.LastModifiedTime
+	}
+	return modifiedTimes, nil
+}
+
+func (c *FakeConnector) DescribeTable(ctx context.Context, dataset, table string) (*connectors.TableMetadata, error) {
+	if err := c.DescribeErr[dataset+"."+table]; err != nil {
+		return nil, err
+	}
+	if t := c.Table(dataset, table); t != nil {
+		described := *t
+		described.Columns = append([]connectors.ColumnMetadata(nil), t.Columns...)
+		return &described, nil
+	}
+	return nil, errors.New("table not found")
+}
+
+func (c *FakeConnector) Close() error {
+	return nil
+}
@@ -0,0 +1,130 @@
+// Package testutil sets up the database and clients that tests of the
+// services and handlers run against.
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/appcontext"
+	"github.com/kerem-kaynak/katalog/internal/config"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"github.com/meilisearch/meilisearch-go"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewContext returns a context backed by a fresh schema of the Postgres
+// database at TEST_DATABASE_URL, migrated like the app's, and a search index
+// that accepts every request. The test is skipped if TEST_DATABASE_URL is not
+// set. The schema is dropped when the test ends.
+func NewContext(t *testing.T) *appcontext.Context {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+
+	schema := "test_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	if err := admin.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\" SCHEMA public").Error; err != nil {
+		t.Fatalf("failed to enable uuid-ossp extension: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("failed to create test schema: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema)), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect to test schema: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := config.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	return &appcontext.Context{
+		DB:                db,
+		Logger:            zap.NewNop(),
+		MeilisearchClient: NewSearchClient(t),
+	}
+}
+
+// withSearchPath sets the search path of a URL or keyword/value connection
+// string, keeping public for the uuid-ossp functions.
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema + ",public"
+	}
+	return dsn + " search_path=" + schema + ",public"
+}
+
+// NewSearchClient returns a Meilisearch client whose server enqueues every
+// task it is sent.
+func NewSearchClient(t *testing.T) *meilisearch.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"taskUid":1,"indexUid":"resources","status":"enqueued","type":"documentAdditionOrUpdate","enqueuedAt":"2024-01-01T00:00:00Z"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	return meilisearch.NewClient(meilisearch.ClientConfig{Host: server.URL})
+}
+
+// CreateProject creates a company and a project in it.
+func CreateProject(t *testing.T, db *gorm.DB) entity.Project {
+	t.Helper()
+
+	company := entity.Company{Name: "Company " + uuid.New().String()}
+	if err := db.Create(&company).Error; err != nil {
+		t.Fatalf("failed to create company: %v", err)
+	}
+
+	project := entity.Project{Name: "Project", CompanyID: company.ID}
+	if err := db.Create(&project).Error; err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+
+	return project
+}
+
+// CreateUser creates a user of the company.
+func CreateUser(t *testing.T, db *gorm.DB, companyID uuid.UUID) entity.User {
+	t.Helper()
+
+	user := entity.User{Name: "User", Email: uuid.New().String() + "@example.com", CompanyID: &companyID}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	return user
+}
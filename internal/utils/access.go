@@ -66,9 +66,60 @@ func UserHasTableAccess(ctx *appcontext.Context, userID uuid.UUID, tableID uuid.
 	return true
 }
 
+// UserHasDatasetHistoryAccess is UserHasDatasetAccess for reading the history
+// of a dataset, which stays accessible after the dataset was deleted.
+func UserHasDatasetHistoryAccess(ctx *appcontext.Context, userID uuid.UUID, datasetID uuid.UUID) bool {
+	var user entity.User
+	var dataset entity.Dataset
+	var project entity.Project
+
+	if err := ctx.DB.Preload("Company").First(&user, userID).Error; err != nil {
+		return false
+	}
+
+	if err := ctx.DB.Unscoped().First(&dataset, datasetID).Error; err != nil {
+		return false
+	}
+
+	if err := ctx.DB.Where("id = ? AND company_id = ?", dataset.ProjectID, user.CompanyID).First(&project).Error; err != nil {
+		return false
+	}
+
+	return true
+}
+
+// UserHasTableHistoryAccess is UserHasTableAccess for reading the history of
+// a table, which stays accessible after the table or its dataset was deleted.
+func UserHasTableHistoryAccess(ctx *appcontext.Context, userID uuid.UUID, tableID uuid.UUID) bool {
+	var user entity.User
+	var table entity.Table
+	var dataset entity.Dataset
+	var project entity.Project
+
+	if err := ctx.DB.Preload("Company").First(&user, userID).Error; err != nil {
+		return false
+	}
+
+	if err := ctx.DB.Unscoped().First(&table, tableID).Error; err != nil {
+		return false
+	}
+
+	if err := ctx.DB.Unscoped().First(&dataset, table.DatasetID).Error; err != nil {
+		return false
+	}
+
+	if err := ctx.DB.Where("id = ? AND company_id = ?", dataset.ProjectID, user.CompanyID).First(&project).Error; err != nil {
+		return false
+	}
+
+	return true
+}
+
+// ProjectHasSync reports whether the project was synced successfully at least
+// once. Running and failed syncs leave no metadata to report on.
 func ProjectHasSync(ctx *appcontext.Context, projectID uuid.UUID) bool {
 	var sync entity.Sync
-	if err := ctx.DB.Where("project_id = ?", projectID).First(&sync).Error; err != nil {
+	if err := ctx.DB.Where("project_id = ? AND status IN ?", projectID, entity.SyncStatusesSucceeded).First(&sync).Error; err != nil {
 		return false
 	}
 
@@ -2,11 +2,13 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/google/uuid"
-	"github.com/kerem-kaynak/katalog/internal/appcontext"
 	"github.com/kerem-kaynak/katalog/internal/entity"
+	"gorm.io/gorm"
 )
 
 func contains(slice []string, item string) bool {
@@ -18,13 +20,18 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-func compareAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, entityType string, entityID uuid.UUID, entityName string, oldEntity, newEntity interface{}, parentID *uuid.UUID, parentName string, grandParentID *uuid.UUID, grandParentName string) {
+func compareAndLogChanges(changes *[]entity.Changelog, syncID *uuid.UUID, entityType string, entityID uuid.UUID, entityName string, oldEntity, newEntity interface{}, parentID *uuid.UUID, parentName string, grandParentID *uuid.UUID, grandParentName string) {
 	oldValue := reflect.ValueOf(oldEntity)
 	newValue := reflect.ValueOf(newEntity)
 
 	for i := 0; i < oldValue.NumField(); i++ {
 		fieldName := oldValue.Type().Field(i).Name
-		if contains([]string{"Model", "Columns", "Tables"}, fieldName) {
+		// LastModifiedTime moves on every write to a table, it is not a schema change.
+		// Curated fields are never changed by a sync, edits are logged as they happen.
+		// Column positions only serve rename detection.
+		// Schema fingerprints only tell the sync whether a table changed.
+		// NumBytes grows and shrinks with the data, like the last modified time.
+		if contains([]string{"Model", "Columns", "Tables", "LastModifiedTime", "CuratedDescription", "Owner", "Tags", "Position", "SchemaFingerprint", "NumBytes"}, fieldName) {
 			continue
 		}
 		oldFieldValue := oldValue.Field(i).Interface()
@@ -43,22 +50,47 @@ func compareAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, entityType
 				ParentName:      parentName,
 				GrandParentID:   grandParentID,
 				GrandParentName: grandParentName,
-				SyncID:          &syncID,
+				SyncID:          syncID,
 			}
-			ctx.DB.Create(&changelog)
+			*changes = append(*changes, changelog)
 		}
 	}
 }
 
-func FetchCurrentState(ctx *appcontext.Context, projectID uuid.UUID) ([]entity.Dataset, error) {
+func FetchCurrentState(db *gorm.DB, projectID uuid.UUID) ([]entity.Dataset, error) {
 	var datasets []entity.Dataset
-	if err := ctx.DB.Preload("Tables.Columns").Where("project_id = ?", projectID).Find(&datasets).Error; err != nil {
+	if err := db.Preload("Tables.Columns").Where("project_id = ?", projectID).Find(&datasets).Error; err != nil {
 		return nil, err
 	}
 	return datasets, nil
 }
 
-func RecordChanges(ctx *appcontext.Context, syncID uuid.UUID, oldDatasets, newDatasets []entity.Dataset) error {
+// changelogBatchSize is the number of changelog entries inserted per
+// statement.
+const changelogBatchSize = 500
+
+// RecordChanges stores the changelog entries of a sync, as computed by
+// DiffStates. It is meant to run in the transaction of the sync, so that the
+// changelog is stored together with the changes it describes or not at all.
+func RecordChanges(tx *gorm.DB, changelogs []entity.Changelog) error {
+	if len(changelogs) == 0 {
+		return nil
+	}
+
+	if err := tx.CreateInBatches(changelogs, changelogBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to create changelogs: %w", err)
+	}
+
+	return nil
+}
+
+// DiffStates returns the changelog entries between the old and new state of
+// a project without storing them. Removed datasets and tables whose IDs are
+// in excluded are logged as excluded by a sync filter rather than as deleted
+// at the source, added entities whose IDs are in restored as restored rather
+// than inserted.
+func DiffStates(projectID uuid.UUID, syncID *uuid.UUID, oldDatasets, newDatasets []entity.Dataset, excluded, restored map[uuid.UUID]bool) []entity.Changelog {
+	var changes []entity.Changelog
 
 	oldDatasetsMap := make(map[uuid.UUID]entity.Dataset)
 	newDatasetsMap := make(map[uuid.UUID]entity.Dataset)
@@ -73,7 +105,7 @@ func RecordChanges(ctx *appcontext.Context, syncID uuid.UUID, oldDatasets, newDa
 		oldDs, exists := oldDatasetsMap[id]
 		if !exists {
 			changelog := entity.Changelog{
-				ChangeType: "insert",
+				ChangeType: additionChangeType(newDs.ID, restored),
 				EntityType: "dataset",
 				EntityID:   newDs.ID,
 				EntityName: newDs.Name,
@@ -81,19 +113,19 @@ func RecordChanges(ctx *appcontext.Context, syncID uuid.UUID, oldDatasets, newDa
 				OldValue:   "",
 				NewValue:   "",
 				ParentName: "",
-				SyncID:     &syncID,
+				SyncID:     syncID,
 			}
-			ctx.DB.Create(&changelog)
+			changes = append(changes, changelog)
 		} else {
-			compareAndLogChanges(ctx, syncID, "dataset", newDs.ID, newDs.Name, oldDs, newDs, nil, "", nil, "")
-			compareTablesAndLogChanges(ctx, syncID, oldDs.Tables, newDs.Tables, newDs.ID, newDs.Name)
+			compareAndLogChanges(&changes, syncID, "dataset", newDs.ID, newDs.Name, oldDs, newDs, nil, "", nil, "")
+			compareTablesAndLogChanges(&changes, syncID, oldDs.Tables, newDs.Tables, newDs.ID, newDs.Name, excluded, restored)
 		}
 		delete(oldDatasetsMap, id)
 	}
 
 	for _, oldDs := range oldDatasetsMap {
 		changelog := entity.Changelog{
-			ChangeType: "delete",
+			ChangeType: removalChangeType(oldDs.ID, excluded),
 			EntityType: "dataset",
 			EntityID:   oldDs.ID,
 			EntityName: oldDs.Name,
@@ -101,15 +133,21 @@ func RecordChanges(ctx *appcontext.Context, syncID uuid.UUID, oldDatasets, newDa
 			OldValue:   "",
 			NewValue:   "",
 			ParentName: "",
-			SyncID:     &syncID,
+			SyncID:     syncID,
 		}
-		ctx.DB.Create(&changelog)
+		changes = append(changes, changelog)
 	}
 
-	return nil
+	for i := range changes {
+		changes[i].Origin = entity.ChangelogOriginSource
+		changes[i].ProjectID = &projectID
+	}
+	classifyChanges(changes, newDatasets)
+
+	return changes
 }
 
-func compareTablesAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, oldTables, newTables []entity.Table, datasetID uuid.UUID, datasetName string) {
+func compareTablesAndLogChanges(changes *[]entity.Changelog, syncID *uuid.UUID, oldTables, newTables []entity.Table, datasetID uuid.UUID, datasetName string, excluded, restored map[uuid.UUID]bool) {
 	oldTablesMap := make(map[uuid.UUID]entity.Table)
 	newTablesMap := make(map[uuid.UUID]entity.Table)
 	for _, tbl := range oldTables {
@@ -123,7 +161,7 @@ func compareTablesAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, oldTa
 		oldTbl, exists := oldTablesMap[id]
 		if !exists {
 			changelog := entity.Changelog{
-				ChangeType: "insert",
+				ChangeType: additionChangeType(newTbl.ID, restored),
 				EntityType: "table",
 				EntityID:   newTbl.ID,
 				EntityName: newTbl.Name,
@@ -132,19 +170,19 @@ func compareTablesAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, oldTa
 				NewValue:   "",
 				ParentID:   &datasetID,
 				ParentName: datasetName,
-				SyncID:     &syncID,
+				SyncID:     syncID,
 			}
-			ctx.DB.Create(&changelog)
+			*changes = append(*changes, changelog)
 		} else {
-			compareAndLogChanges(ctx, syncID, "table", newTbl.ID, newTbl.Name, oldTbl, newTbl, &datasetID, datasetName, nil, "")
-			compareColumnsAndLogChanges(ctx, syncID, oldTbl.Columns, newTbl.Columns, newTbl.ID, newTbl.Name, datasetID, datasetName)
+			compareAndLogChanges(changes, syncID, "table", newTbl.ID, newTbl.Name, oldTbl, newTbl, &datasetID, datasetName, nil, "")
+			compareColumnsAndLogChanges(changes, syncID, oldTbl.Columns, newTbl.Columns, newTbl.ID, newTbl.Name, datasetID, datasetName, restored)
 		}
 		delete(oldTablesMap, id)
 	}
 
 	for _, oldTbl := range oldTablesMap {
 		changelog := entity.Changelog{
-			ChangeType: "delete",
+			ChangeType: removalChangeType(oldTbl.ID, excluded),
 			EntityType: "table",
 			EntityID:   oldTbl.ID,
 			EntityName: oldTbl.Name,
@@ -153,13 +191,13 @@ func compareTablesAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, oldTa
 			NewValue:   "",
 			ParentID:   &datasetID,
 			ParentName: datasetName,
-			SyncID:     &syncID,
+			SyncID:     syncID,
 		}
-		ctx.DB.Create(&changelog)
+		*changes = append(*changes, changelog)
 	}
 }
 
-func compareColumnsAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, oldColumns, newColumns []entity.Column, tableID uuid.UUID, tableName string, datasetID uuid.UUID, datasetName string) {
+func compareColumnsAndLogChanges(changes *[]entity.Changelog, syncID *uuid.UUID, oldColumns, newColumns []entity.Column, tableID uuid.UUID, tableName string, datasetID uuid.UUID, datasetName string, restored map[uuid.UUID]bool) {
 	oldColumnsMap := make(map[uuid.UUID]entity.Column)
 	newColumnsMap := make(map[uuid.UUID]entity.Column)
 	for _, col := range oldColumns {
@@ -169,36 +207,69 @@ func compareColumnsAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, oldC
 		newColumnsMap[col.ID] = col
 	}
 
+	var addedColumns []entity.Column
 	for id, newCol := range newColumnsMap {
 		oldCol, exists := oldColumnsMap[id]
 		if !exists {
-			changelog := entity.Changelog{
-				ChangeType:      "insert",
-				EntityType:      "column",
-				EntityID:        newCol.ID,
-				EntityName:      newCol.Name,
-				FieldName:       "",
-				OldValue:        "",
-				NewValue:        "",
-				ParentID:        &tableID,
-				ParentName:      tableName,
-				GrandParentID:   &datasetID,
-				GrandParentName: datasetName,
-				SyncID:          &syncID,
-			}
-			ctx.DB.Create(&changelog)
+			addedColumns = append(addedColumns, newCol)
 		} else {
-			compareAndLogChanges(ctx, syncID, "column", newCol.ID, newCol.Name, oldCol, newCol, &tableID, tableName, &datasetID, datasetName)
+			compareAndLogChanges(changes, syncID, "column", newCol.ID, newCol.Path, oldCol, newCol, &tableID, tableName, &datasetID, datasetName)
 		}
 		delete(oldColumnsMap, id)
 	}
 
+	var removedColumns []entity.Column
+	for _, oldCol := range oldColumnsMap {
+		removedColumns = append(removedColumns, oldCol)
+	}
+
+	renames := DetectColumnRenames(removedColumns, addedColumns, positionsPopulated(oldColumns))
+	for oldID, newCol := range renames {
+		changelog := entity.Changelog{
+			ChangeType:      "rename",
+			EntityType:      "column",
+			EntityID:        newCol.ID,
+			EntityName:      newCol.Path,
+			FieldName:       "Path",
+			OldValue:        toJSON(oldColumnsMap[oldID].Path),
+			NewValue:        toJSON(newCol.Path),
+			ParentID:        &tableID,
+			ParentName:      tableName,
+			GrandParentID:   &datasetID,
+			GrandParentName: datasetName,
+			SyncID:          syncID,
+		}
+		*changes = append(*changes, changelog)
+		delete(oldColumnsMap, oldID)
+	}
+
+	for _, newCol := range addedColumns {
+		if renamed(renames, newCol.ID) {
+			continue
+		}
+		changelog := entity.Changelog{
+			ChangeType:      additionChangeType(newCol.ID, restored),
+			EntityType:      "column",
+			EntityID:        newCol.ID,
+			EntityName:      newCol.Path,
+			FieldName:       "",
+			OldValue:        "",
+			NewValue:        "",
+			ParentID:        &tableID,
+			ParentName:      tableName,
+			GrandParentID:   &datasetID,
+			GrandParentName: datasetName,
+			SyncID:          syncID,
+		}
+		*changes = append(*changes, changelog)
+	}
+
 	for _, oldCol := range oldColumnsMap {
 		changelog := entity.Changelog{
 			ChangeType:      "delete",
 			EntityType:      "column",
 			EntityID:        oldCol.ID,
-			EntityName:      oldCol.Name,
+			EntityName:      oldCol.Path,
 			FieldName:       "",
 			OldValue:        "",
 			NewValue:        "",
@@ -206,10 +277,133 @@ func compareColumnsAndLogChanges(ctx *appcontext.Context, syncID uuid.UUID, oldC
 			ParentName:      tableName,
 			GrandParentID:   &datasetID,
 			GrandParentName: datasetName,
-			SyncID:          &syncID,
+			SyncID:          syncID,
+		}
+		*changes = append(*changes, changelog)
+	}
+}
+
+// DetectColumnRenames pairs columns removed from a table with columns added
+// to it in the same sync, keyed by the ID of the removed column. A pair is a
+// likely rename if both columns sit at the same position under the same
+// parent and share their type and description, and neither column matches
+// any other column. The fields of a renamed RECORD column are matched as if
+// their parent had kept its name. Positions are only compared if positioned
+// is set, see positionsPopulated.
+func DetectColumnRenames(removed, added []entity.Column, positioned bool) map[uuid.UUID]entity.Column {
+	type renameKey struct {
+		parentPath  string
+		position    int
+		columnType  string
+		description string
+	}
+	keyOf := func(col entity.Column, parentPath string) renameKey {
+		position := col.Position
+		if !positioned {
+			position = 0
+		}
+		return renameKey{parentPath, position, col.Type, col.Description}
+	}
+
+	maxDepth := 0
+	for _, col := range append(append([]entity.Column(nil), removed...), added...) {
+		if col.Depth > maxDepth {
+			maxDepth = col.Depth
+		}
+	}
+
+	// Parents are paired before their fields, so that the fields of a renamed
+	// parent are looked up under its new path
+	renames := make(map[uuid.UUID]entity.Column)
+	renamedPaths := make(map[string]string)
+	for depth := 0; depth <= maxDepth; depth++ {
+		removedByKey := make(map[renameKey][]entity.Column)
+		for _, col := range removed {
+			if col.Depth != depth {
+				continue
+			}
+			parent := parentPath(col.Path)
+			if newParent, ok := renamedPaths[parent]; ok {
+				parent = newParent
+			}
+			removedByKey[keyOf(col, parent)] = append(removedByKey[keyOf(col, parent)], col)
 		}
-		ctx.DB.Create(&changelog)
+		addedByKey := make(map[renameKey][]entity.Column)
+		for _, col := range added {
+			if col.Depth != depth {
+				continue
+			}
+			addedByKey[keyOf(col, parentPath(col.Path))] = append(addedByKey[keyOf(col, parentPath(col.Path))], col)
+		}
+
+		for key, removedCols := range removedByKey {
+			addedCols := addedByKey[key]
+			if len(removedCols) == 1 && len(addedCols) == 1 {
+				renames[removedCols[0].ID] = addedCols[0]
+				renamedPaths[removedCols[0].Path] = addedCols[0].Path
+			}
+		}
+	}
+
+	return renames
+}
+
+// positionsPopulated reports whether the positions of a table's columns were
+// recorded. Columns stored before positions were recorded all sit at position
+// 0, until the next sync that reads the table.
+func positionsPopulated(columns []entity.Column) bool {
+	type siblings struct {
+		count       int
+		maxPosition int
+	}
+	byParent := make(map[string]*siblings)
+	for _, col := range columns {
+		parent := parentPath(col.Path)
+		if byParent[parent] == nil {
+			byParent[parent] = &siblings{}
+		}
+		byParent[parent].count++
+		if col.Position > byParent[parent].maxPosition {
+			byParent[parent].maxPosition = col.Position
+		}
+	}
+
+	for _, s := range byParent {
+		if s.count > 1 && s.maxPosition == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func parentPath(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func renamed(renames map[uuid.UUID]entity.Column, columnID uuid.UUID) bool {
+	for _, col := range renames {
+		if col.ID == columnID {
+			return true
+		}
+	}
+	return false
+}
+
+func additionChangeType(entityID uuid.UUID, restored map[uuid.UUID]bool) string {
+	if restored[entityID] {
+		return "restore"
+	}
+	return "insert"
+}
+
+func removalChangeType(entityID uuid.UUID, excluded map[uuid.UUID]bool) string {
+	if excluded[entityID] {
+		return "exclude"
 	}
+	return "delete"
 }
 
 func toJSON(v interface{}) string {
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+)
+
+func column(path string, position int, columnType string) entity.Column {
+	name := path
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		name = path[i+1:]
+	}
+	return entity.Column{
+		ID:       uuid.New(),
+		Name:     name,
+		Path:     path,
+		Depth:    strings.Count(path, "."),
+		Position: position,
+		Type:     columnType,
+	}
+}
+
+// renamedPaths returns the renames as "old->new" paths.
+func renamedPaths(removed []entity.Column, renames map[uuid.UUID]entity.Column) map[string]bool {
+	paths := make(map[string]bool)
+	for _, col := range removed {
+		if renamed, ok := renames[col.ID]; ok {
+			paths[col.Path+"->"+renamed.Path] = true
+		}
+	}
+	return paths
+}
+
+func TestDetectColumnRenames(t *testing.T) {
+	tests := []struct {
+		name       string
+		removed    []entity.Column
+		added      []entity.Column
+		positioned bool
+		want       []string
+	}{
+		{
+			name:       "rename",
+			removed:    []entity.Column{column("amount", 1, "INT64")},
+			added:      []entity.Column{column("total", 1, "INT64")},
+			positioned: true,
+			want:       []string{"amount->total"},
+		},
+		{
+			name:       "type changed",
+			removed:    []entity.Column{column("amount", 1, "INT64")},
+			added:      []entity.Column{column("total", 1, "NUMERIC")},
+			positioned: true,
+		},
+		{
+			name:       "position changed",
+			removed:    []entity.Column{column("amount", 1, "INT64")},
+			added:      []entity.Column{column("total", 3, "INT64")},
+			positioned: true,
+		},
+		{
+			name:       "ambiguous",
+			removed:    []entity.Column{column("a", 1, "STRING"), column("b", 1, "STRING")},
+			added:      []entity.Column{column("c", 1, "STRING")},
+			positioned: false,
+		},
+		{
+			name:       "positions not recorded",
+			removed:    []entity.Column{column("amount", 0, "INT64"), column("note", 0, "STRING")},
+			added:      []entity.Column{column("total", 4, "INT64")},
+			positioned: false,
+			want:       []string{"amount->total"},
+		},
+		{
+			name:       "nested field",
+			removed:    []entity.Column{column("customer.name", 0, "STRING")},
+			added:      []entity.Column{column("customer.full_name", 0, "STRING")},
+			positioned: true,
+			want:       []string{"customer.name->customer.full_name"},
+		},
+		{
+			name:       "same field under another parent",
+			removed:    []entity.Column{column("customer.name", 0, "STRING")},
+			added:      []entity.Column{column("supplier.name", 0, "STRING")},
+			positioned: true,
+		},
+		{
+			name: "renamed record",
+			removed: []entity.Column{
+				column("customer", 2, "RECORD"),
+				column("customer.name", 0, "STRING"),
+				column("customer.address", 1, "RECORD"),
+				column("customer.address.city", 0, "STRING"),
+			},
+			added: []entity.Column{
+				column("client", 2, "RECORD"),
+				column("client.name", 0, "STRING"),
+				column("client.address", 1, "RECORD"),
+				column("client.address.city", 0, "STRING"),
+			},
+			positioned: true,
+			want: []string{
+				"customer->client",
+				"customer.name->client.name",
+				"customer.address->client.address",
+				"customer.address.city->client.address.city",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := renamedPaths(test.removed, DetectColumnRenames(test.removed, test.added, test.positioned))
+			if len(got) != len(test.want) {
+				t.Errorf("renames = %v, want %v", got, test.want)
+			}
+			for _, rename := range test.want {
+				if !got[rename] {
+					t.Errorf("renames = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPositionsPopulated(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []entity.Column
+		want    bool
+	}{
+		{"single column", []entity.Column{column("id", 0, "INT64")}, true},
+		{"recorded", []entity.Column{column("id", 0, "INT64"), column("amount", 1, "INT64")}, true},
+		{"not recorded", []entity.Column{column("id", 0, "INT64"), column("amount", 0, "INT64")}, false},
+		{"nested fields not recorded", []entity.Column{
+			column("id", 0, "INT64"),
+			column("customer", 1, "RECORD"),
+			column("customer.name", 0, "STRING"),
+			column("customer.email", 0, "STRING"),
+		}, false},
+	}
+
+	for _, test := range tests {
+		if got := positionsPopulated(test.columns); got != test.want {
+			t.Errorf("%s: positionsPopulated = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDiffStatesRenamedRecord(t *testing.T) {
+	projectID := uuid.New()
+	datasetID := uuid.New()
+	tableID := uuid.New()
+
+	state := func(columns ...entity.Column) []entity.Dataset {
+		for i := range columns {
+			columns[i].TableID = tableID
+		}
+		return []entity.Dataset{{ID: datasetID, Name: "sales", Tables: []entity.Table{{ID: tableID, Name: "orders", DatasetID: datasetID, Columns: columns}}}}
+	}
+
+	id := column("id", 0, "INT64")
+	oldState := state(id, column("customer", 1, "RECORD"), column("customer.name", 0, "STRING"))
+	newState := state(id, column("client", 1, "RECORD"), column("client.name", 0, "STRING"))
+
+	changelogs := DiffStates(projectID, nil, oldState, newState, nil, nil)
+
+	renames := make(map[string]bool)
+	for _, changelog := range changelogs {
+		if changelog.ChangeType != "rename" {
+			t.Errorf("unexpected %s of %s", changelog.ChangeType, changelog.EntityName)
+			continue
+		}
+		renames[changelog.OldValue+"->"+changelog.NewValue] = true
+	}
+	if !renames[`"customer"->"client"`] || !renames[`"customer.name"->"client.name"`] || len(renames) != 2 {
+		t.Errorf("renames = %v, want customer and customer.name renamed", renames)
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	projectID := uuid.New()
+	syncID := uuid.New()
+
+	id := column("id", 0, "INT64")
+	amount := column("amount", 1, "INT64")
+	widened := amount
+	widened.Type = "NUMERIC"
+	createdAt := column("created_at", 2, "TIMESTAMP")
+	refundID := column("id", 0, "INT64")
+
+	orders := entity.Table{ID: uuid.New(), Name: "orders", Columns: []entity.Column{id, amount}}
+	refunds := entity.Table{ID: uuid.New(), Name: "refunds", Columns: []entity.Column{refundID}}
+	customers := entity.Table{ID: uuid.New(), Name: "customers"}
+	sales := entity.Dataset{ID: uuid.New(), Name: "sales", Tables: []entity.Table{orders, refunds}}
+	archive := entity.Dataset{ID: uuid.New(), Name: "archive"}
+	marketing := entity.Dataset{ID: uuid.New(), Name: "marketing"}
+
+	newOrders := orders
+	newOrders.Description = "All orders"
+	newOrders.Columns = []entity.Column{id, widened, createdAt}
+	newSales := sales
+	newSales.Tables = []entity.Table{newOrders, customers}
+
+	changelogs := DiffStates(projectID, &syncID,
+		[]entity.Dataset{sales, archive},
+		[]entity.Dataset{newSales, marketing},
+		map[uuid.UUID]bool{refunds.ID: true},
+		map[uuid.UUID]bool{customers.ID: true})
+
+	got := make(map[string]bool)
+	for _, changelog := range changelogs {
+		got[changelog.ChangeType+" "+changelog.EntityType+" "+changelog.EntityName+" "+changelog.FieldName] = true
+
+		if changelog.ProjectID == nil || *changelog.ProjectID != projectID || changelog.SyncID == nil || *changelog.SyncID != syncID {
+			t.Errorf("%s of %s has project %v and sync %v", changelog.ChangeType, changelog.EntityName, changelog.ProjectID, changelog.SyncID)
+		}
+		if changelog.Origin != entity.ChangelogOriginSource {
+			t.Errorf("%s of %s has origin %s", changelog.ChangeType, changelog.EntityName, changelog.Origin)
+		}
+		if changelog.EntityType == "column" && (changelog.ParentName != "orders" || changelog.GrandParentName != "sales") {
+			t.Errorf("%s of %s has parents %s, %s, want orders, sales", changelog.ChangeType, changelog.EntityName, changelog.ParentName, changelog.GrandParentName)
+		}
+	}
+
+	want := []string{
+		"insert dataset marketing ",
+		"delete dataset archive ",
+		"restore table customers ",
+		"exclude table refunds ",
+		"update table orders Description",
+		"update column amount Type",
+		"insert column created_at ",
+	}
+	for _, change := range want {
+		if !got[change] {
+			t.Errorf("no %q in %v", change, got)
+		}
+	}
+	if len(changelogs) != len(want) {
+		t.Errorf("got %d changelogs, want %d: %v", len(changelogs), len(want), got)
+	}
+}
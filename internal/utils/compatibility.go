@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+)
+
+// changeImpact describes how a change affects consumers. backward is false if
+// consumers on the new schema can no longer read data written with the old
+// one, forward is false if consumers on the old schema can no longer read
+// data written with the new one.
+type changeImpact struct {
+	severity string
+	backward bool
+	forward  bool
+}
+
+var (
+	safeChange       = changeImpact{entity.ChangeSeveritySafe, true, true}
+	droppedEntity    = changeImpact{entity.ChangeSeverityBreaking, true, false}
+	incompatible     = changeImpact{entity.ChangeSeverityBreaking, false, false}
+	newRequiredField = changeImpact{entity.ChangeSeverityPotentiallyBreaking, false, true}
+	widened          = changeImpact{entity.ChangeSeverityPotentiallyBreaking, true, false}
+	narrowed         = changeImpact{entity.ChangeSeverityBreaking, false, true}
+	layoutChange     = changeImpact{entity.ChangeSeverityPotentiallyBreaking, true, true}
+)
+
+// classifyChanges sets the severity of every changelog entry. Columns of the
+// new state are needed to tell whether an added column is required.
+func classifyChanges(changes []entity.Changelog, newDatasets []entity.Dataset) {
+	newColumns := make(map[uuid.UUID]entity.Column)
+	for _, ds := range newDatasets {
+		for _, tbl := range ds.Tables {
+			for _, col := range tbl.Columns {
+				newColumns[col.ID] = col
+			}
+		}
+	}
+
+	for i := range changes {
+		changes[i].Severity = impactOf(&changes[i], newColumns).severity
+	}
+}
+
+func impactOf(change *entity.Changelog, newColumns map[uuid.UUID]entity.Column) changeImpact {
+	switch change.ChangeType {
+	case "delete":
+		return droppedEntity
+	case "rename":
+		return incompatible
+	case "insert", "restore":
+		// Without the new state at hand, as when a stored entry is checked
+		// against a policy, the severity it was classified with tells whether
+		// the column was added as required
+		if newColumns == nil && change.Severity == entity.ChangeSeverityPotentiallyBreaking {
+			return newRequiredField
+		}
+		if change.EntityType == "column" && newColumns[change.EntityID].Mode == "REQUIRED" {
+			return newRequiredField
+		}
+		return safeChange
+	case "update":
+		return updateImpact(change)
+	}
+
+	return safeChange
+}
+
+func updateImpact(change *entity.Changelog) changeImpact {
+	var oldValue, newValue string
+	json.Unmarshal([]byte(change.OldValue), &oldValue)
+	json.Unmarshal([]byte(change.NewValue), &newValue)
+
+	switch change.EntityType + "." + change.FieldName {
+	case "column.Type":
+		switch {
+		case typeWidens(oldValue, newValue):
+			return widened
+		case typeWidens(newValue, oldValue):
+			return narrowed
+		}
+		return incompatible
+	case "column.Mode":
+		switch {
+		case oldValue == "":
+			// Modes of columns synced before modes were tracked are unknown
+			return safeChange
+		case oldValue == "REPEATED" || newValue == "REPEATED":
+			return incompatible
+		case newValue == "REQUIRED":
+			return narrowed
+		case oldValue == "REQUIRED":
+			return widened
+		}
+	case "table.Type", "table.TimePartitioning", "table.RangePartitioning", "table.Clustering":
+		return layoutChange
+	}
+
+	return safeChange
+}
+
+// ViolatesPolicy reports whether a change breaks the given compatibility
+// level.
+func ViolatesPolicy(change *entity.Changelog, level string) bool {
+	impact := impactOf(change, nil)
+
+	switch level {
+	case entity.CompatibilityBackward:
+		return !impact.backward
+	case entity.CompatibilityForward:
+		return !impact.forward
+	case entity.CompatibilityFull:
+		return !impact.backward || !impact.forward
+	}
+	return false
+}
+
+// ApplyCompatibilityPolicies flags changes that violate the compatibility
+// policy of their dataset. policies maps dataset IDs to policy levels.
+func ApplyCompatibilityPolicies(changes []entity.Changelog, policies map[uuid.UUID]string) {
+	for i := range changes {
+		change := &changes[i]
+
+		var datasetID *uuid.UUID
+		switch change.EntityType {
+		case "dataset":
+			datasetID = &change.EntityID
+		case "table":
+			datasetID = change.ParentID
+		case "column":
+			datasetID = change.GrandParentID
+		}
+		if datasetID == nil {
+			continue
+		}
+
+		level, ok := policies[*datasetID]
+		if ok && ViolatesPolicy(change, level) {
+			change.PolicyViolation = level
+		}
+	}
+}
+
+var typePattern = regexp.MustCompile(`^([a-z0-9 _]+?)\s*(?:\((\d+)(?:\s*,\s*(\d+))?\))?$`)
+
+// Ranks of types that can be widened into each other. A type widens into
+// another type of the same family with a higher rank.
+var typeRanks = map[string]struct {
+	family string
+	rank   int
+}{
+	"tinyint":           {"number", 1},
+	"smallint":          {"number", 2},
+	"mediumint":         {"number", 3},
+	"int":               {"number", 4},
+	"integer":           {"number", 4},
+	"bigint":            {"number", 5},
+	"int64":             {"number", 5},
+	"numeric":           {"number", 6},
+	"decimal":           {"number", 6},
+	"bignumeric":        {"number", 7},
+	"real":              {"number", 8},
+	"float":             {"number", 8},
+	"double":            {"number", 9},
+	"double precision":  {"number", 9},
+	"float64":           {"number", 9},
+	"char":              {"text", 1},
+	"character":         {"text", 1},
+	"varchar":           {"text", 2},
+	"character varying": {"text", 2},
+	"text":              {"text", 3},
+	"string":            {"text", 3},
+	"date":              {"time", 1},
+	"datetime":          {"time", 2},
+	"timestamp":         {"time", 2},
+}
+
+// columnType is a parsed column type. length is the length or precision of
+// the type and scale the number of its decimal digits, both 0 if the type has
+// none. Display widths of integer types, e.g. the 11 of MySQL's int(11), are
+// dropped since they do not limit the values a column holds.
+type columnType struct {
+	base     string
+	length   int
+	scale    int
+	unsigned bool
+}
+
+// typeWidens reports whether a column of type from can be changed to type to
+// without losing values, e.g. INT64 to NUMERIC or VARCHAR(50) to VARCHAR(100).
+func typeWidens(from, to string) bool {
+	fromType, fromOK := parseType(from)
+	toType, toOK := parseType(to)
+	if !fromOK || !toOK {
+		return false
+	}
+
+	// Negative values do not fit into an unsigned type
+	if !fromType.unsigned && toType.unsigned {
+		return false
+	}
+
+	if fromType.base == toType.base {
+		if fromType.unsigned != toType.unsigned {
+			// The upper half of an unsigned type does not fit into the signed one
+			return false
+		}
+		// A length is only ever lifted or increased, e.g. VARCHAR(10) to VARCHAR,
+		// and neither the integer nor the decimal digits of a precision shrink
+		if fromType.length == 0 {
+			return false
+		}
+		if toType.length == 0 {
+			return true
+		}
+		return toType.length > fromType.length && toType.scale >= fromType.scale &&
+			toType.length-toType.scale >= fromType.length-fromType.scale
+	}
+
+	if fromType.length > 0 && toType.length > 0 && (toType.length < fromType.length || toType.scale < fromType.scale) {
+		return false
+	}
+
+	fromRank, fromKnown := typeRanks[fromType.base]
+	toRank, toKnown := typeRanks[toType.base]
+	return fromKnown && toKnown && fromRank.family == toRank.family && toRank.rank > fromRank.rank
+}
+
+// parseType parses types as reported by the connectors, e.g. INT64,
+// character varying(255), decimal(10,2) or MySQL's int(11) unsigned zerofill.
+func parseType(raw string) (columnType, bool) {
+	var parsed columnType
+
+	var words []string
+	for _, word := range strings.Fields(strings.ToLower(raw)) {
+		switch word {
+		case "unsigned":
+			parsed.unsigned = true
+		case "zerofill":
+		default:
+			words = append(words, word)
+		}
+	}
+
+	matches := typePattern.FindStringSubmatch(strings.Join(words, " "))
+	if matches == nil {
+		return columnType{}, false
+	}
+
+	parsed.base = strings.TrimSpace(matches[1])
+	if rank, ok := typeRanks[parsed.base]; ok && rank.family == "number" && rank.rank <= typeRanks["bigint"].rank {
+		return parsed, true
+	}
+	if matches[2] != "" {
+		parsed.length, _ = strconv.Atoi(matches[2])
+	}
+	if matches[3] != "" {
+		parsed.scale, _ = strconv.Atoi(matches[3])
+	}
+	return parsed, true
+}
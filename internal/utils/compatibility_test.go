@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+)
+
+func TestTypeWidens(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"INT64", "NUMERIC", true},
+		{"NUMERIC", "INT64", false},
+		{"INT64", "FLOAT64", true},
+		{"STRING", "INT64", false},
+		{"DATE", "TIMESTAMP", true},
+		{"VARCHAR(50)", "VARCHAR(100)", true},
+		{"varchar(255)", "varchar(100)", false},
+		{"varchar(255)", "varchar(255)", false},
+		{"character varying(10)", "character varying", true},
+		{"character varying", "character varying(10)", false},
+		{"varchar(255)", "text", true},
+		{"char(10)", "varchar(20)", true},
+		{"char(10)", "varchar(5)", false},
+		{"integer", "bigint", true},
+		{"int(11)", "bigint(20)", true},
+		{"int(11)", "int(10)", false},
+		{"int(10)", "int(11)", false},
+		{"int(11) unsigned", "bigint(20)", true},
+		{"int(11) unsigned", "int(11)", false},
+		{"int(11)", "int(11) unsigned", false},
+		{"int(11)", "bigint(20) unsigned", false},
+		{"int(10) unsigned zerofill", "int(10) unsigned", false},
+		{"int(10) unsigned zerofill", "bigint unsigned", true},
+		{"decimal(10,2)", "decimal(12,2)", true},
+		{"decimal(10,2)", "decimal(12,4)", true},
+		{"decimal(10,2)", "decimal(10,4)", false},
+		{"decimal(10,4)", "decimal(12,2)", false},
+		{"decimal(10,2)", "decimal", true},
+		{"numeric(10, 2)", "numeric(12, 2)", true},
+		{"tinyint(1)", "smallint(6)", true},
+		{"", "INT64", false},
+	}
+
+	for _, test := range tests {
+		if got := typeWidens(test.from, test.to); got != test.want {
+			t.Errorf("typeWidens(%q, %q) = %v, want %v", test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func changeOf(changeType, entityType, fieldName, oldValue, newValue string) entity.Changelog {
+	encode := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+	return entity.Changelog{
+		ID:         uuid.New(),
+		EntityID:   uuid.New(),
+		ChangeType: changeType,
+		EntityType: entityType,
+		FieldName:  fieldName,
+		OldValue:   encode(oldValue),
+		NewValue:   encode(newValue),
+	}
+}
+
+func TestClassifyChanges(t *testing.T) {
+	required := changeOf("insert", "column", "", "", "")
+	nullable := changeOf("insert", "column", "", "", "")
+	newState := []entity.Dataset{{Tables: []entity.Table{{Columns: []entity.Column{
+		{ID: required.EntityID, Mode: "REQUIRED"},
+		{ID: nullable.EntityID, Mode: "NULLABLE"},
+	}}}}}
+
+	tests := []struct {
+		name   string
+		change entity.Changelog
+		want   string
+	}{
+		{"dataset insert", changeOf("insert", "dataset", "", "", ""), entity.ChangeSeveritySafe},
+		{"nullable column insert", nullable, entity.ChangeSeveritySafe},
+		{"required column insert", required, entity.ChangeSeverityPotentiallyBreaking},
+		{"table delete", changeOf("delete", "table", "", "", ""), entity.ChangeSeverityBreaking},
+		{"column rename", changeOf("rename", "column", "", "amount", "total"), entity.ChangeSeverityBreaking},
+		{"description update", changeOf("update", "column", "Description", "old", "new"), entity.ChangeSeveritySafe},
+		{"type widened", changeOf("update", "column", "Type", "INT64", "NUMERIC"), entity.ChangeSeverityPotentiallyBreaking},
+		{"type narrowed", changeOf("update", "column", "Type", "varchar(255)", "varchar(100)"), entity.ChangeSeverityBreaking},
+		{"type changed", changeOf("update", "column", "Type", "STRING", "INT64"), entity.ChangeSeverityBreaking},
+		{"mode required", changeOf("update", "column", "Mode", "NULLABLE", "REQUIRED"), entity.ChangeSeverityBreaking},
+		{"mode nullable", changeOf("update", "column", "Mode", "REQUIRED", "NULLABLE"), entity.ChangeSeverityPotentiallyBreaking},
+		{"mode repeated", changeOf("update", "column", "Mode", "NULLABLE", "REPEATED"), entity.ChangeSeverityBreaking},
+		{"mode unknown", changeOf("update", "column", "Mode", "", "REQUIRED"), entity.ChangeSeveritySafe},
+		{"clustering", changeOf("update", "table", "Clustering", "a", "b"), entity.ChangeSeverityPotentiallyBreaking},
+	}
+
+	changes := make([]entity.Changelog, len(tests))
+	for i, test := range tests {
+		changes[i] = test.change
+	}
+	classifyChanges(changes, newState)
+
+	for i, test := range tests {
+		if changes[i].Severity != test.want {
+			t.Errorf("%s: severity = %s, want %s", test.name, changes[i].Severity, test.want)
+		}
+	}
+}
+
+func TestViolatesPolicy(t *testing.T) {
+	requiredInsert := changeOf("insert", "column", "", "", "")
+	requiredInsert.Severity = entity.ChangeSeverityPotentiallyBreaking
+
+	tests := []struct {
+		name                    string
+		change                  entity.Changelog
+		backward, forward, full bool
+	}{
+		{"nullable column insert", changeOf("insert", "column", "", "", ""), false, false, false},
+		{"required column insert", requiredInsert, true, false, true},
+		{"column delete", changeOf("delete", "column", "", "", ""), false, true, true},
+		{"type widened", changeOf("update", "column", "Type", "INT64", "NUMERIC"), false, true, true},
+		{"type narrowed", changeOf("update", "column", "Type", "NUMERIC", "INT64"), true, false, true},
+		{"column rename", changeOf("rename", "column", "", "amount", "total"), true, true, true},
+	}
+
+	for _, test := range tests {
+		for level, want := range map[string]bool{
+			entity.CompatibilityBackward: test.backward,
+			entity.CompatibilityForward:  test.forward,
+			entity.CompatibilityFull:     test.full,
+		} {
+			if got := ViolatesPolicy(&test.change, level); got != want {
+				t.Errorf("%s: ViolatesPolicy(%s) = %v, want %v", test.name, level, got, want)
+			}
+		}
+	}
+}
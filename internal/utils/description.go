@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kerem-kaynak/katalog/internal/entity"
+	"gorm.io/gorm"
+)
+
+// EffectiveDescription returns the description to show for an entity, given
+// its source and curated descriptions and the project's precedence.
+func EffectiveDescription(precedence, source, curated string) string {
+	if precedence == entity.DescriptionPrecedenceSource {
+		if source != "" {
+			return source
+		}
+		return curated
+	}
+
+	if curated != "" {
+		return curated
+	}
+	return source
+}
+
+func ValidDescriptionPrecedence(precedence string) bool {
+	return precedence == entity.DescriptionPrecedenceCurated || precedence == entity.DescriptionPrecedenceSource
+}
+
+// DatasetDescriptionPrecedence returns the description precedence of the
+// project the dataset belongs to.
+func DatasetDescriptionPrecedence(db *gorm.DB, datasetID uuid.UUID) (string, error) {
+	var precedence string
+	if err := db.Model(&entity.Project{}).
+		Select("projects.description_precedence").
+		Joins("JOIN datasets ON datasets.project_id = projects.id").
+		Where("datasets.id = ?", datasetID).
+		Scan(&precedence).Error; err != nil {
+		return "", fmt.Errorf("failed to get description precedence: %w", err)
+	}
+	return precedence, nil
+}
+
+// TableDescriptionPrecedence returns the description precedence of the
+// project the table belongs to.
+func TableDescriptionPrecedence(db *gorm.DB, tableID uuid.UUID) (string, error) {
+	var precedence string
+	if err := db.Model(&entity.Project{}).
+		Select("projects.description_precedence").
+		Joins("JOIN datasets ON datasets.project_id = projects.id").
+		Joins("JOIN tables ON tables.dataset_id = datasets.id").
+		Where("tables.id = ?", tableID).
+		Scan(&precedence).Error; err != nil {
+		return "", fmt.Errorf("failed to get description precedence: %w", err)
+	}
+	return precedence, nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/kerem-kaynak/katalog/internal/entity"
@@ -9,11 +10,14 @@ import (
 
 func DatasetToDocument(dataset *entity.Dataset) map[string]interface{} {
 	return map[string]interface{}{
-		"id":          dataset.ID.String(),
-		"type":        "dataset",
-		"name":        dataset.Name,
-		"description": dataset.Description,
-		"project_id":  dataset.ProjectID.String(),
+		"id":                  dataset.ID.String(),
+		"type":                "dataset",
+		"name":                dataset.Name,
+		"description":         dataset.Description,
+		"curated_description": dataset.CuratedDescription,
+		"owner":               dataset.Owner,
+		"tags":                tagList(dataset.Tags),
+		"project_id":          dataset.ProjectID.String(),
 	}
 }
 
@@ -24,15 +28,19 @@ func TableToDocument(db *gorm.DB, table *entity.Table) (map[string]interface{},
 	}
 
 	return map[string]interface{}{
-		"id":           table.ID.String(),
-		"type":         "table",
-		"name":         table.Name,
-		"description":  table.Description,
-		"row_count":    table.RowCount,
-		"project_id":   dataset.ProjectID.String(),
-		"parent_id":    table.DatasetID.String(),
-		"dataset_id":   table.DatasetID.String(),
-		"dataset_name": dataset.Name,
+		"id":                  table.ID.String(),
+		"type":                "table",
+		"name":                table.Name,
+		"description":         table.Description,
+		"curated_description": table.CuratedDescription,
+		"owner":               table.Owner,
+		"tags":                tagList(table.Tags),
+		"table_type":          table.Type,
+		"row_count":           table.RowCount,
+		"project_id":          dataset.ProjectID.String(),
+		"parent_id":           table.DatasetID.String(),
+		"dataset_id":          table.DatasetID.String(),
+		"dataset_name":        dataset.Name,
 	}, nil
 }
 
@@ -47,21 +55,41 @@ func ColumnToDocument(db *gorm.DB, column *entity.Column) (map[string]interface{
 		return nil, fmt.Errorf("failed to fetch dataset for column: %w", err)
 	}
 
+	var parentColumnID string
+	if column.ParentID != nil {
+		parentColumnID = column.ParentID.String()
+	}
+
 	return map[string]interface{}{
-		"id":           column.ID.String(),
-		"type":         "column",
-		"name":         column.Name,
-		"description":  column.Description,
-		"column_type":  column.Type,
-		"project_id":   dataset.ProjectID.String(),
-		"parent_id":    column.TableID.String(),
-		"table_id":     column.TableID.String(),
-		"dataset_id":   table.DatasetID.String(),
-		"table_name":   table.Name,
-		"dataset_name": dataset.Name,
+		"id":                  column.ID.String(),
+		"type":                "column",
+		"name":                column.Name,
+		"path":                column.Path,
+		"depth":               column.Depth,
+		"parent_column_id":    parentColumnID,
+		"description":         column.Description,
+		"curated_description": column.CuratedDescription,
+		"owner":               column.Owner,
+		"tags":                tagList(column.Tags),
+		"column_type":         column.Type,
+		"project_id":          dataset.ProjectID.String(),
+		"parent_id":           column.TableID.String(),
+		"table_id":            column.TableID.String(),
+		"dataset_id":          table.DatasetID.String(),
+		"table_name":          table.Name,
+		"dataset_name":        dataset.Name,
 	}, nil
 }
 
+// tagList decodes the tags of an entity, stored as a JSON array.
+func tagList(tags string) []string {
+	list := []string{}
+	if tags != "" {
+		json.Unmarshal([]byte(tags), &list)
+	}
+	return list
+}
+
 // func IndexDocument(ctx *appcontext.Context, document map[string]interface{}) error {
 // 	_, err := ctx.MeilisearchClient.Index("resources").AddDocuments([]map[string]interface{}{document})
 // 	if err != nil {